@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ArchiveEndpoint is the URL of an S3 endpoint to use instead of a cluster
+	ArchiveEndpoint string
+
+	// ArchiveAccessKey and ArchiveSecretKey are the credentials of ArchiveEndpoint
+	ArchiveAccessKey string
+	ArchiveSecretKey string
+
+	// ArchiveRegion is the region of ArchiveEndpoint
+	ArchiveRegion string
+)
+
+// CliClusterExport is the Cobra CLI call
+func CliClusterExport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [NAME] FILE",
+		Short: "Export the buckets and objects of a cluster to an archive",
+		Long: "Export every bucket of a cluster to a tar archive, compressed when FILE ends with .gz or .tgz.\n" +
+			"The archive holds the objects with their metadata, tags and ACL, and the versioning, ACL, \n" +
+			"policy and lifecycle of the buckets. Only the latest version of the objects is exported. \n" +
+			"ACLs keep their grants to single users, these users must exist where the archive is imported. \n" +
+			"With --endpoint, any S3 endpoint can be exported instead of a cluster.",
+		Args: archiveArgs,
+		Run:  exportNano,
+		Example: "cn cluster export mycluster dataset.tar.gz \n" +
+			"cn cluster export --endpoint https://s3.amazonaws.com dataset.tar.gz",
+	}
+	archiveFlags(cmd)
+
+	return cmd
+}
+
+// CliClusterImport is the Cobra CLI call
+func CliClusterImport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [NAME] FILE",
+		Short: "Import the buckets and objects of an archive into a cluster",
+		Long: "Import an archive written by 'cn cluster export' into a cluster.\n" +
+			"Existing buckets are kept and existing objects are overwritten. \n" +
+			"With --endpoint, the archive is imported into any S3 endpoint instead of a cluster.",
+		Args: archiveArgs,
+		Run:  importNano,
+		Example: "cn cluster import mycluster dataset.tar.gz \n" +
+			"cn cluster import --endpoint https://s3.amazonaws.com --region eu-west-1 dataset.tar.gz",
+	}
+	archiveFlags(cmd)
+
+	return cmd
+}
+
+// archiveFlags adds the flags pointing export and import at an S3 endpoint
+func archiveFlags(cmd *cobra.Command) {
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&ArchiveEndpoint, "endpoint", "", "URL of an S3 endpoint to use instead of a cluster")
+	cmd.Flags().StringVar(&ArchiveAccessKey, "access-key", os.Getenv("AWS_ACCESS_KEY_ID"), "Access key of the endpoint (default $AWS_ACCESS_KEY_ID)")
+	cmd.Flags().StringVar(&ArchiveSecretKey, "secret-key", os.Getenv("AWS_SECRET_ACCESS_KEY"), "Secret key of the endpoint (default $AWS_SECRET_ACCESS_KEY)")
+	cmd.Flags().StringVar(&ArchiveRegion, "region", os.Getenv("AWS_DEFAULT_REGION"), "Region of the endpoint (default $AWS_DEFAULT_REGION or "+nano.DefaultRegion+")")
+}
+
+// archiveArgs requires a cluster name unless an endpoint is given
+func archiveArgs(cmd *cobra.Command, args []string) error {
+	if ArchiveEndpoint != "" {
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+	return cobra.ExactArgs(2)(cmd, args)
+}
+
+// archiveEndpoint returns the endpoint export and import work on, and the archive file
+func archiveEndpoint(args []string) (*nano.S3Endpoint, string) {
+	if ArchiveEndpoint != "" {
+		return &nano.S3Endpoint{
+			URL:       ArchiveEndpoint,
+			AccessKey: ArchiveAccessKey,
+			SecretKey: ArchiveSecretKey,
+			Region:    ArchiveRegion,
+		}, args[0]
+	}
+
+	ContainerName := ContainerNamePrefix + args[0]
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	cluster := getCluster(ContainerName)
+	waitCluster(cluster)
+	endpoint, err := cluster.S3Endpoint(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return endpoint, args[1]
+}
+
+// exportNano exports the buckets of a cluster
+func exportNano(cmd *cobra.Command, args []string) {
+	endpoint, fileName := archiveEndpoint(args)
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var writer io.Writer = file
+	var compressor *gzip.Writer
+	if strings.HasSuffix(fileName, ".gz") || strings.HasSuffix(fileName, ".tgz") {
+		compressor = gzip.NewWriter(file)
+		writer = compressor
+	}
+
+	index, err := endpoint.Export(ctx, writer)
+	if err == nil && compressor != nil {
+		err = compressor.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fileName)
+		log.Fatal(err)
+	}
+	fmt.Printf("Exported %s to %s\n", archiveSummary(index), fileName)
+}
+
+// importNano imports an archive into a cluster
+func importNano(cmd *cobra.Command, args []string) {
+	endpoint, fileName := archiveEndpoint(args)
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	// Compressed archives are recognized by their magic number, whatever their name
+	reader := bufio.NewReader(file)
+	var archive io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		if archive, err = gzip.NewReader(reader); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	index, err := endpoint.Import(ctx, archive)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Imported %s from %s\n", archiveSummary(index), fileName)
+}
+
+// archiveSummary counts the buckets and objects of an archive
+func archiveSummary(index *nano.ArchiveIndex) string {
+	objects := 0
+	for _, bucket := range index.Buckets {
+		objects += len(bucket.Objects)
+	}
+	return fmt.Sprintf("%d bucket(s) and %d object(s)", len(index.Buckets), objects)
+}
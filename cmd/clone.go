@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ClonePort pins the port of the S3 gateway of the clone
+	ClonePort int
+
+	// ClonePortRange is the range of ports scanned for the S3 gateway of the clone
+	ClonePortRange string
+
+	// CloneBindAddress is the host address the S3 gateway of the clone is published on
+	CloneBindAddress string
+)
+
+// CliClusterClone is the Cobra CLI call
+func CliClusterClone() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clone SRC DST",
+		Short: "Create a new cluster holding a copy of the data of another one",
+		Long: "Create cluster DST from the image, resources and data of cluster SRC.\n" +
+			"The S3 gateway of DST is published on a free port, a running SRC is stopped while its data is copied. \n" +
+			"Both clusters share the S3 keys of SRC.",
+		Args: cobra.ExactArgs(2),
+		Run:  cloneNano,
+		Example: "cn cluster clone seeded ci-job-1 \n" +
+			"cn cluster clone seeded ci-job-2 --port 9002",
+	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().IntVar(&ClonePort, "port", 0, "Port of the S3 gateway, fails if it is busy (default the first free port of --port-range)")
+	cmd.Flags().StringVar(&ClonePortRange, "port-range", fmt.Sprintf("%d-%d", nano.DefaultMinPort, nano.DefaultMaxPort), "Range of ports scanned for a free one")
+	cmd.Flags().StringVar(&CloneBindAddress, "bind", "", "Host address the S3 gateway is published on (default the one of SRC)")
+
+	return cmd
+}
+
+// cloneNano clones a cluster
+func cloneNano(cmd *cobra.Command, args []string) {
+	defer cancelOnInterrupt()()
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	if containerState(ContainerNamePrefix+args[1]) != "" {
+		log.Fatal("Cluster " + args[1] + " already exists, please choose another name.")
+	}
+	minPort, maxPort, err := parsePortRange(ClonePortRange)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintln(infoOutput(), "Cloning cluster "+args[0]+" into "+args[1]+"...")
+	clone, err := getCluster(ContainerName).Clone(ctx, nano.Options{
+		Name:        args[1],
+		BindAddress: CloneBindAddress,
+		Port:        ClonePort,
+		MinPort:     minPort,
+		MaxPort:     maxPort,
+		Progress:    infoOutput(),
+	})
+	if err != nil {
+		checkHealthError(err)
+	}
+	echoInfo(clone)
+}
@@ -17,6 +17,15 @@ func init() {
 		CliClusterList(),
 		CliClusterStart(),
 		CliClusterStatus(),
+		CliClusterWait(),
+		CliClusterEnv(),
+		CliClusterSeed(),
+		CliClusterSnapshot(),
+		CliClusterRestore(),
+		CliClusterClone(),
+		CliClusterUpgrade(),
+		CliClusterExport(),
+		CliClusterImport(),
 		CliClusterStop(),
 		CliClusterRestart(),
 		CliClusterLogs(),
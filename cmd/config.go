@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ceph/cn/nano"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ConfigFile is the project config file, cn.yaml in the current directory by default
+	ConfigFile = "cn.yaml"
+)
+
+// cnConfig is the content of a cn config file
+type cnConfig struct {
+	Clusters map[string]clusterConfig `yaml:"clusters"`
+}
+
+// clusterConfig defines a cluster, empty fields keep the defaults of 'cn cluster start'
+type clusterConfig struct {
+	Image         string       `yaml:"image"`
+	WorkDir       string       `yaml:"work_dir"`
+	Privileged    bool         `yaml:"privileged"`
+	Persist       bool         `yaml:"persist"`
+	Memory        string       `yaml:"memory"`
+	CPUs          float64      `yaml:"cpus"`
+	Size          string       `yaml:"size"`
+	Port          int          `yaml:"port"`
+	PortRange     string       `yaml:"port_range"`
+	Bind          string       `yaml:"bind"`
+	Users         []userConfig `yaml:"users"`
+	Buckets       []string     `yaml:"buckets"`
+	Seed          string       `yaml:"seed"`
+	DebugInterval string       `yaml:"debug_interval"`
+}
+
+// userConfig defines an S3 user created along with the cluster, keys are generated when empty
+type userConfig struct {
+	UID         string `yaml:"uid"`
+	DisplayName string `yaml:"display_name"`
+	AccessKey   string `yaml:"access_key"`
+	SecretKey   string `yaml:"secret_key"`
+}
+
+// userConfigFile returns the path of the user config file
+func userConfigFile() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(homeDir(), ".config")
+	}
+	return filepath.Join(configHome, "cn", "config.yaml")
+}
+
+// loadConfig reads the user config file then the project one, a cluster defined in both comes from the project
+// it also returns the names of the clusters defined by the project, sorted
+func loadConfig() (map[string]clusterConfig, []string) {
+	clusters := map[string]clusterConfig{}
+	readConfigFile(userConfigFile(), clusters)
+	projectClusters := readConfigFile(ConfigFile, clusters)
+
+	var names []string
+	for name := range projectClusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return clusters, names
+}
+
+// readConfigFile adds the clusters of a config file to clusters, a missing file defines no cluster
+// relative work directories and seed manifests are relative to the directory of the file
+func readConfigFile(fileName string, clusters map[string]clusterConfig) map[string]clusterConfig {
+	content, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var config cnConfig
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		log.Fatal("Unable to read " + fileName + ": " + err.Error())
+	}
+	for name, cluster := range config.Clusters {
+		cluster.WorkDir = configPath(fileName, cluster.WorkDir)
+		cluster.Seed = configPath(fileName, cluster.Seed)
+		config.Clusters[name] = cluster
+		clusters[name] = cluster
+	}
+	return config.Clusters
+}
+
+// configPath resolves a path found in a config file against the directory of the file
+func configPath(fileName string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	dir, err := filepath.Abs(filepath.Join(filepath.Dir(fileName), path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return dir
+}
+
+// nanoUsers converts the users of a config file
+func (c clusterConfig) nanoUsers() []nano.User {
+	var users []nano.User
+	for _, user := range c.Users {
+		users = append(users, nano.User{
+			UID:         user.UID,
+			DisplayName: user.DisplayName,
+			AccessKey:   user.AccessKey,
+			SecretKey:   user.SecretKey,
+		})
+	}
+	return users
+}
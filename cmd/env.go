@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/ceph/cn/nano"
+	"github.com/go-ini/ini"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// EnvShell is the shell the variables are printed for
+	EnvShell string
+
+	// EnvWriteProfile also writes the credentials in the aws-cli and s3cmd configuration files
+	EnvWriteProfile bool
+
+	// EnvProfile is the name of the aws-cli profile to write
+	EnvProfile string
+)
+
+// envRegion is the region given to the SDKs, the Rados Gateway accepts any
+const envRegion = "us-east-1"
+
+// CliClusterEnv is the Cobra CLI call
+func CliClusterEnv() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env NAME",
+		Short: "Print the environment to use the S3 gateway with AWS SDKs and CLIs",
+		Long: "Print the environment to use the S3 gateway with AWS SDKs and CLIs.\n" +
+			"With --write-profile, a profile is also written in ~/.aws/config and ~/.aws/credentials \n" +
+			"and an s3cmd configuration in ~/.s3cfg-PROFILE, to use with 's3cmd -c'.",
+		Args: cobra.ExactArgs(1),
+		Run:  envNano,
+		Example: "eval $(cn cluster env mycluster) \n" +
+			"cn cluster env mycluster --shell fish | source \n" +
+			"cn cluster env mycluster --shell powershell | Invoke-Expression \n" +
+			"cn cluster env mycluster --write-profile && aws --profile nano-mycluster s3 ls \n" +
+			"cn cluster env mycluster --write-profile && s3cmd -c ~/.s3cfg-nano-mycluster ls",
+	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&EnvShell, "shell", "", "Shell to print the variables for: bash, zsh, fish or powershell (default from $SHELL)")
+	cmd.Flags().BoolVar(&EnvWriteProfile, "write-profile", false, "Write an aws-cli profile and the s3cmd configuration for the cluster")
+	cmd.Flags().StringVar(&EnvProfile, "profile", "", "Name of the aws-cli profile to write (default nano-NAME)")
+
+	return cmd
+}
+
+// envNano prints the variables pointing AWS tools at a cluster
+func envNano(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	cluster := getCluster(ContainerName)
+	waitCluster(cluster)
+
+	if EnvWriteProfile {
+		profile := EnvProfile
+		if profile == "" {
+			profile = "nano-" + cluster.Name
+		}
+		writeProfile(cluster, profile)
+	}
+
+	variables := [][2]string{
+		{"AWS_ACCESS_KEY_ID", cluster.AccessKey},
+		{"AWS_SECRET_ACCESS_KEY", cluster.SecretKey},
+		{"AWS_ENDPOINT_URL", cluster.Endpoint},
+		{"AWS_DEFAULT_REGION", envRegion},
+	}
+
+	if structuredOutput() {
+		document := map[string]string{}
+		for _, variable := range variables {
+			document[variable[0]] = variable[1]
+		}
+		printDocument(document)
+		return
+	}
+
+	shell := EnvShell
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+	switch shell {
+	case "fish":
+		for _, variable := range variables {
+			fmt.Printf("set -gx %s %s;\n", variable[0], shellQuote("fish", variable[1]))
+		}
+		fmt.Println("# Run this command to configure your shell:")
+		fmt.Println("# cn cluster env " + cluster.Name + " | source")
+	case "powershell", "pwsh":
+		for _, variable := range variables {
+			fmt.Printf("$Env:%s = %s\n", variable[0], shellQuote("powershell", variable[1]))
+		}
+		fmt.Println("# Run this command to configure your shell:")
+		fmt.Println("# & cn cluster env " + cluster.Name + " --shell powershell | Invoke-Expression")
+	case "bash", "zsh", "sh", "ksh", ".", "":
+		for _, variable := range variables {
+			fmt.Printf("export %s=%s\n", variable[0], shellQuote("sh", variable[1]))
+		}
+		fmt.Println("# Run this command to configure your shell:")
+		fmt.Println("# eval $(cn cluster env " + cluster.Name + ")")
+	default:
+		log.Fatal("Unsupported shell '" + shell + "', please use bash, zsh, fish or powershell.")
+	}
+}
+
+// shellQuote single-quotes a value for a shell, so nothing in it is expanded
+// each shell has its own way of escaping the single quotes inside the value
+func shellQuote(shell string, value string) string {
+	switch shell {
+	case "fish":
+		// Backslashes escape themselves and single quotes
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+	case "powershell":
+		// Typographic single quotes are quotes too
+		return "'" + strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b").Replace(value) + "'"
+	}
+	// Close the quotes, add an escaped quote and open them again
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// writeProfile writes the credentials of a cluster as an aws-cli profile and as an s3cmd configuration of the profile
+// only the keys cn knows about are changed, the rest of the files is kept
+func writeProfile(cluster *nano.Cluster, profile string) {
+	home := homeDir()
+
+	credentialsFile := filepath.Join(home, ".aws", "credentials")
+	updateIniFile(credentialsFile, profile, map[string]string{
+		"aws_access_key_id":     cluster.AccessKey,
+		"aws_secret_access_key": cluster.SecretKey,
+	})
+
+	// Apart from the default one, profiles are prefixed in the config file
+	configSection := "profile " + profile
+	if profile == "default" {
+		configSection = profile
+	}
+	configFile := filepath.Join(home, ".aws", "config")
+	updateIniFile(configFile, configSection, map[string]string{
+		"region":       envRegion,
+		"endpoint_url": cluster.Endpoint,
+	})
+
+	// s3cmd has no profiles and only reads the default section, each profile gets a file of its own
+	// so that the configuration of the user, ~/.s3cfg, is left alone
+	endpoint, err := url.Parse(cluster.Endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	useHTTPS := "False"
+	if endpoint.Scheme == "https" {
+		useHTTPS = "True"
+	}
+	s3cfgFile := filepath.Join(home, ".s3cfg-"+profile)
+	updateIniFile(s3cfgFile, "default", map[string]string{
+		"access_key":  cluster.AccessKey,
+		"secret_key":  cluster.SecretKey,
+		"host_base":   endpoint.Host,
+		"host_bucket": endpoint.Host,
+		"use_https":   useHTTPS,
+	})
+
+	fmt.Fprintln(os.Stderr, "Profile "+profile+" written in "+credentialsFile+" and "+configFile+", use it with s3cmd -c "+s3cfgFile)
+}
+
+// updateIniFile sets keys in a section of an ini file, the file and the section are created if needed
+func updateIniFile(fileName string, section string, keys map[string]string) {
+	cfg, err := ini.LooseLoad(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for key, value := range keys {
+		cfg.Section(section).Key(key).SetValue(value)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		log.Fatal(err)
+	}
+	// The files hold secrets, keep them private
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := cfg.WriteTo(file); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// homeDir returns the home directory of the user running cn
+func homeDir() string {
+	if home := os.Getenv("HOME"); home != "" {
+		return home
+	}
+	current, err := user.Current()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return current.HomeDir
+}
@@ -38,6 +38,8 @@ func listImageTags(cmd *cobra.Command, args []string) {
 		numPage = pageCount()
 	}
 
+	// Tags are collected instead of printed for structured outputs
+	tags := []string{}
 	for i := 1; i <= numPage; i++ {
 		// convert numPage into a string for concatenation, (see the end)
 		url = "https://registry.hub.docker.com/v2/repositories/ceph/daemon/tags/?page=" + strconv.Itoa(i)
@@ -48,6 +50,24 @@ func listImageTags(cmd *cobra.Command, args []string) {
 		if err != nil {
 			panic(err)
 		}
-		parseMap(m, "name")
+		if !structuredOutput() {
+			parseMap(m, "name")
+			continue
+		}
+		var page struct {
+			Results []struct {
+				Name string `json:"name"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(output), &page); err != nil {
+			panic(err)
+		}
+		for _, result := range page.Results {
+			tags = append(tags, result.Name)
+		}
+	}
+
+	if structuredOutput() {
+		printDocument(imageTagsDocument{Image: "ceph/daemon", Tags: tags})
 	}
 }
@@ -1,13 +1,10 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"log"
-	"strings"
 
-	"github.com/docker/docker/api/types"
 	"github.com/spf13/cobra"
 )
 
@@ -27,44 +24,26 @@ func CliImageUpdate() *cobra.Command {
 
 // updateNano updates the container image
 func updateNano(cmd *cobra.Command, args []string) {
-	ImageName := args[0]
+	ImageName = args[0]
 
 	if !pullImage() {
-		events, err := getDocker().ImagePull(ctx, ImageName, types.ImagePullOptions{})
+		// The image was already there, it is updated if pulling it again changes its ID
+		before, err := getRuntime().InspectImage(ctx, ImageName)
 		if err != nil {
 			log.Fatal(err)
 		}
-
-		d := json.NewDecoder(events)
-
-		type Event struct {
-			Status         string `json:"status"`
-			Error          string `json:"error"`
-			Progress       string `json:"progress"`
-			ProgressDetail struct {
-				Current int `json:"current"`
-				Total   int `json:"total"`
-			} `json:"progressDetail"`
+		if err := getRuntime().PullImage(ctx, ImageName, ioutil.Discard); err != nil {
+			log.Fatal(err)
 		}
-
-		var event *Event
-		for {
-			if err := d.Decode(&event); err != nil {
-				if err == io.EOF {
-					break
-				}
-				log.Fatal(err)
-			}
+		after, err := getRuntime().InspectImage(ctx, ImageName)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		if event != nil {
-			if strings.Contains(event.Status, fmt.Sprintf("Downloaded newer image for %s", ImageName)) {
-				fmt.Println("New image " + ImageName + " downloaded.")
-			}
-
-			if strings.Contains(event.Status, fmt.Sprintf("Image is up to date for %s", ImageName)) {
-				fmt.Println("Image " + ImageName + " is up to date.")
-			}
+		if before.ID != after.ID {
+			fmt.Println("New image " + ImageName + " downloaded.")
+		} else {
+			fmt.Println("Image " + ImageName + " is up to date.")
 		}
 	}
 }
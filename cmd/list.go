@@ -3,10 +3,9 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"regexp"
 
 	"github.com/apcera/termtables"
-	"github.com/docker/docker/api/types"
+	"github.com/ceph/cn/nano"
 	"github.com/spf13/cobra"
 )
 
@@ -27,32 +26,28 @@ func listNano(cmd *cobra.Command, args []string) {
 }
 
 func showNanoClusters() {
-	listOptions := types.ContainerListOptions{
-		All:   true,
-		Quiet: true,
-	}
-	containers, err := getDocker().ContainerList(ctx, listOptions)
+	clusters, err := nano.List(ctx, getRuntime())
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	table := termtables.CreateTable()
-	table.AddHeaders("NAME", "STATUS", "IMAGE", "IMAGE RELEASE", "IMAGE CREATION TIME")
-
-	// run the loop on both indexes, it's fine they have the same length
-	for _, container := range containers {
-		for i := range container.Names {
-			match, _ := regexp.MatchString(ContainerNamePrefix, container.Names[i])
-			if match {
-				// remove 7 first char since container.ImageID is in the form of sha256:<ID>
-				containerImgTag := inspectImage(container.ImageID[7:], "tag")
-				containerImgCreated := inspectImage(container.ImageID[7:], "created")
-				containerImgRelease := inspectImage(container.ImageID[7:], "release")
-				ContainerNameToShow := container.Names[i][len(ContainerNamePrefix):]
-				// We trim again so we can remove the '/' since container name returned is /ceph-nano
-				table.AddRow(ContainerNameToShow[1:], container.State, containerImgTag, containerImgRelease, containerImgCreated)
-			}
+	if structuredOutput() {
+		documents := []clusterDocument{}
+		for _, cluster := range clusters {
+			documents = append(documents, newClusterDocument(cluster))
 		}
+		printDocument(documents)
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.AddHeaders("NAME", "STATUS", "IMAGE", "IMAGE RELEASE", "IMAGE CREATION TIME", "RESOURCES")
+
+	for _, cluster := range clusters {
+		containerImgTag := inspectImage(cluster.ImageID, "tag")
+		containerImgCreated := inspectImage(cluster.ImageID, "created")
+		containerImgRelease := inspectImage(cluster.ImageID, "release")
+		table.AddRow(cluster.Name, cluster.State, containerImgTag, containerImgRelease, containerImgCreated, formatResources(cluster))
 	}
 	fmt.Println(table.Render())
 }
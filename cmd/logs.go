@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -26,7 +27,9 @@ func logsNano(cmd *cobra.Command, args []string) {
 
 func showS3Logs(ContainerName string) {
 	notExistCheck(ContainerName)
-	c := []string{"cat", "/var/log/ceph/client.rgw." + ContainerName + "-faa32aebf00b.log"}
-	output := execContainer(ContainerName, c)
+	output, err := getCluster(ContainerName).S3Logs(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Printf("%s", output)
 }
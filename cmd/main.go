@@ -3,11 +3,11 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 
-	"github.com/docker/docker/client"
+	"github.com/ceph/cn/nano"
 	"github.com/spf13/cobra"
 )
 
@@ -21,77 +21,36 @@ var (
 	cnVersion = "undefined"
 
 	// WorkingDirectory is the working directory where objects can be put inside S3
-	WorkingDirectory = "/usr/share/ceph-nano"
-
-	// CephNanoUID is the uid of the S3 user
-	CephNanoUID = "nano"
+	WorkingDirectory = nano.DefaultWorkDir
 
 	// ContainerNamePrefix is name of the container
-	ContainerNamePrefix = "ceph-nano-"
+	ContainerNamePrefix = nano.ContainerNamePrefix
 
 	// ImageName is the name of the container image
-	ImageName = "ceph/daemon"
+	ImageName = nano.DefaultImage
+
+	// RuntimeName is the container runtime to use, "docker" or "podman"
+	RuntimeName = os.Getenv("CN_RUNTIME")
 
-	// TempPath is the temporary path inside the container
-	TempPath = "/tmp/"
+	// runtimeCli is the runtime in use, initialized on first use
+	runtimeCli nano.Runtime
 
 	rootCmd = &cobra.Command{
 		Use:        cliName,
 		Short:      cliDescription,
 		SuggestFor: []string{"cn"},
 		//Long:
+		PersistentPreRun: checkOutputFormat,
 	}
 
-	// dockerCli initializes the client connection
-	dockerCli *client.Client
-
-	// ctx opens context
-	ctx = context.Background()
+	// ctx opens context, it is cancelled on Ctrl-C while a command waits on it, see cancelOnInterrupt
+	ctx, cancelCtx = context.WithCancel(context.Background())
 )
 
-func getDocker() *client.Client {
-	// If the connection with docker is not yet established
-	if dockerCli == nil {
-		cli, err := client.NewEnvClient()
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		// Let's make a first Docker command to check if the protocol is consistent
-		var apiVersion string
-		_, err = cli.Info(ctx)
-		if err != nil {
-			// Oops, unable to handle server's protocol
-			serverVersion := fmt.Sprint(err)
-			if strings.Contains(serverVersion, "is too new") {
-				ss := strings.SplitAfter(serverVersion, "Maximum supported API version is ")
-				apiVersion = ss[1]
-			} else if strings.Contains(serverVersion, "client is newer than server") {
-				ss := strings.SplitAfter(serverVersion, "server API version: ")
-				// trim last character since this 'ss[1]' is '1.24.'
-				apiVersion = ss[1][:len(ss[1])-1]
-			} else {
-				// That's an error we don't know, let's stop here
-				log.Fatal(err)
-			}
-
-			// The client version shall be degraded as it's greater than the server's one
-			if len(apiVersion) > 0 {
-				os.Setenv("DOCKER_API_VERSION", apiVersion)
-				fmt.Println("Warning: Degrading Docker client API version to " + apiVersion + " to match server's version")
-				// As the DOCKER_API_VERSION variable is updated, we have to restart the communication to get it
-				return getDocker()
-			}
-		}
-		// Ok, the Docker connection is valid & functional, let's return that context
-		dockerCli = cli
-	}
-	return dockerCli
-}
-
 // Main is the main function calling the whole program
 func Main(version string) {
 	cnVersion = version
+	nano.Version = version
 	validateEnv()
 
 	if err := rootCmd.Execute(); err != nil {
@@ -100,11 +59,37 @@ func Main(version string) {
 	}
 }
 
+// cancelOnInterrupt cancels ctx on Ctrl-C or SIGTERM until the returned function is called
+// only the commands waiting on ctx install it, Ctrl-C kills the others right away, as does a second signal
+func cancelOnInterrupt() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			cancelCtx()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&RuntimeName, "runtime", RuntimeName, "Container runtime to use, 'docker' or 'podman' (default from CN_RUNTIME, else docker)")
+	rootCmd.PersistentFlags().StringVar(&ConfigFile, "config", ConfigFile, "Project config file defining clusters")
+	rootCmd.PersistentFlags().StringVarP(&OutputFormat, "output", "o", OutputFormat, "Output format: table, json or yaml")
 	rootCmd.AddCommand(
 		cmdCluster,
 		cmdS3,
 		cmdImage,
+		cmdVolume,
+		cmdUser,
+		cmdQuota,
 		CliVersionNano(),
 	)
 }
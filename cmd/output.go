@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// OutputFormat is the format of the command output: table, json or yaml
+	OutputFormat = "table"
+)
+
+// clusterDocument is the structured output describing a cluster
+type clusterDocument struct {
+	Name         string     `json:"name" yaml:"name"`
+	State        string     `json:"state" yaml:"state"`
+	Health       string     `json:"health,omitempty" yaml:"health,omitempty"`
+	Endpoint     string     `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	BindAddress  string     `json:"bind_address,omitempty" yaml:"bind_address,omitempty"`
+	User         string     `json:"user,omitempty" yaml:"user,omitempty"`
+	AccessKey    string     `json:"access_key,omitempty" yaml:"access_key,omitempty"`
+	SecretKey    string     `json:"secret_key,omitempty" yaml:"secret_key,omitempty"`
+	WorkDir      string     `json:"work_dir,omitempty" yaml:"work_dir,omitempty"`
+	Memory       int64      `json:"memory" yaml:"memory"`
+	CPUs         float64    `json:"cpus" yaml:"cpus"`
+	Size         int64      `json:"size" yaml:"size"`
+	Image        string     `json:"image" yaml:"image"`
+	Release      string     `json:"release,omitempty" yaml:"release,omitempty"`
+	ImageCreated string     `json:"image_created,omitempty" yaml:"image_created,omitempty"`
+	Created      *time.Time `json:"created,omitempty" yaml:"created,omitempty"`
+	CnVersion    string     `json:"cn_version,omitempty" yaml:"cn_version,omitempty"`
+	ClonedFrom   string     `json:"cloned_from,omitempty" yaml:"cloned_from,omitempty"`
+}
+
+// imageTagsDocument is the structured output listing the tags of an image
+type imageTagsDocument struct {
+	Image string   `json:"image" yaml:"image"`
+	Tags  []string `json:"tags" yaml:"tags"`
+}
+
+// userDocument is the structured output describing an S3 user
+type userDocument struct {
+	UID         string            `json:"uid" yaml:"uid"`
+	DisplayName string            `json:"display_name" yaml:"display_name"`
+	Suspended   bool              `json:"suspended" yaml:"suspended"`
+	AccessKey   string            `json:"access_key" yaml:"access_key"`
+	SecretKey   string            `json:"secret_key" yaml:"secret_key"`
+	Subusers    []subuserDocument `json:"subusers" yaml:"subusers"`
+}
+
+// subuserDocument is the structured output describing a subuser
+type subuserDocument struct {
+	ID          string `json:"id" yaml:"id"`
+	Permissions string `json:"permissions" yaml:"permissions"`
+	AccessKey   string `json:"access_key" yaml:"access_key"`
+	SecretKey   string `json:"secret_key" yaml:"secret_key"`
+}
+
+// volumeDocument is the structured output describing a volume of a persistent cluster
+type volumeDocument struct {
+	Name       string `json:"name" yaml:"name"`
+	Cluster    string `json:"cluster" yaml:"cluster"`
+	Orphan     bool   `json:"orphan" yaml:"orphan"`
+	Mountpoint string `json:"mountpoint,omitempty" yaml:"mountpoint,omitempty"`
+}
+
+// s3BucketDocument is the structured output describing a bucket
+type s3BucketDocument struct {
+	Bucket   string     `json:"bucket" yaml:"bucket"`
+	URL      string     `json:"url" yaml:"url"`
+	Status   string     `json:"status,omitempty" yaml:"status,omitempty"`
+	Created  *time.Time `json:"created,omitempty" yaml:"created,omitempty"`
+	Location string     `json:"location,omitempty" yaml:"location,omitempty"`
+	Policy   string     `json:"policy,omitempty" yaml:"policy,omitempty"`
+}
+
+// s3ListDocument is the structured output listing the objects of a bucket
+type s3ListDocument struct {
+	Bucket  string             `json:"bucket" yaml:"bucket"`
+	Prefix  string             `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	URL     string             `json:"url" yaml:"url"`
+	Created *time.Time         `json:"created,omitempty" yaml:"created,omitempty"`
+	Objects []s3ObjectDocument `json:"objects" yaml:"objects"`
+}
+
+// s3UsageDocument is the structured output describing the space used by a bucket or a prefix
+type s3UsageDocument struct {
+	Bucket  string          `json:"bucket" yaml:"bucket"`
+	Prefix  string          `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	URL     string          `json:"url" yaml:"url"`
+	Objects int             `json:"objects" yaml:"objects"`
+	Size    int64           `json:"size" yaml:"size"`
+	Quotas  []quotaDocument `json:"quotas,omitempty" yaml:"quotas,omitempty"`
+}
+
+// quotaDocument is the structured output describing the quota of a user or bucket
+type quotaDocument struct {
+	Scope      string `json:"scope" yaml:"scope"`
+	Name       string `json:"name" yaml:"name"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	MaxSize    int64  `json:"max_size" yaml:"max_size"`
+	MaxObjects int64  `json:"max_objects" yaml:"max_objects"`
+	Size       int64  `json:"size" yaml:"size"`
+	Objects    int64  `json:"objects" yaml:"objects"`
+}
+
+// s3ObjectDocument is the structured output describing an object, or a prefix when Dir is set
+type s3ObjectDocument struct {
+	Key          string              `json:"key" yaml:"key"`
+	URL          string              `json:"url" yaml:"url"`
+	Dir          bool                `json:"dir,omitempty" yaml:"dir,omitempty"`
+	Size         int64               `json:"size" yaml:"size"`
+	LastModified *time.Time          `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+	ContentType  string              `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	ETag         string              `json:"etag,omitempty" yaml:"etag,omitempty"`
+	Metadata     map[string][]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	VersionID    string              `json:"version_id,omitempty" yaml:"version_id,omitempty"`
+	Latest       bool                `json:"latest,omitempty" yaml:"latest,omitempty"`
+	DeleteMarker bool                `json:"delete_marker,omitempty" yaml:"delete_marker,omitempty"`
+}
+
+// s3VersioningDocument is the structured output describing the versioning of a bucket
+type s3VersioningDocument struct {
+	Bucket string `json:"bucket" yaml:"bucket"`
+	URL    string `json:"url" yaml:"url"`
+	Status string `json:"status" yaml:"status"`
+}
+
+// s3ACLDocument is the structured output describing the ACL of a bucket or object
+type s3ACLDocument struct {
+	URL    string            `json:"url" yaml:"url"`
+	Owner  string            `json:"owner" yaml:"owner"`
+	Canned string            `json:"canned" yaml:"canned"`
+	Grants []s3GrantDocument `json:"grants" yaml:"grants"`
+}
+
+// s3GrantDocument is the structured output describing a grant of an ACL
+type s3GrantDocument struct {
+	Grantee    string `json:"grantee" yaml:"grantee"`
+	Group      bool   `json:"group,omitempty" yaml:"group,omitempty"`
+	Permission string `json:"permission" yaml:"permission"`
+}
+
+// s3TransferDocument is the structured output describing an object uploaded, downloaded, copied, moved or deleted
+type s3TransferDocument struct {
+	Action      string `json:"action" yaml:"action"`
+	Source      string `json:"source,omitempty" yaml:"source,omitempty"`
+	Destination string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Size        int64  `json:"size" yaml:"size"`
+}
+
+// s3SyncDocument is the structured output describing a directory synchronization
+type s3SyncDocument struct {
+	Source      string               `json:"source" yaml:"source"`
+	Destination string               `json:"destination" yaml:"destination"`
+	Uploaded    int                  `json:"uploaded" yaml:"uploaded"`
+	Skipped     int                  `json:"skipped" yaml:"skipped"`
+	Transfers   []s3TransferDocument `json:"transfers" yaml:"transfers"`
+}
+
+// s3PresignDocument is the structured output describing a presigned URL or POST policy
+type s3PresignDocument struct {
+	Method  string            `json:"method" yaml:"method"`
+	URL     string            `json:"url" yaml:"url"`
+	Expires time.Time         `json:"expires" yaml:"expires"`
+	Fields  map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// checkOutputFormat validates --output before any command runs
+func checkOutputFormat(cmd *cobra.Command, args []string) {
+	switch OutputFormat {
+	case "table", "json", "yaml":
+	default:
+		log.Fatal("Unsupported output format '" + OutputFormat + "', please use table, json or yaml.")
+	}
+}
+
+// structuredOutput tells if a JSON or YAML document is expected instead of the human output
+func structuredOutput() bool {
+	return OutputFormat == "json" || OutputFormat == "yaml"
+}
+
+// infoOutput is where progress messages go, stderr when stdout is reserved for a document
+func infoOutput() io.Writer {
+	if structuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// printDocument prints a document in the format selected with --output
+func printDocument(document interface{}) {
+	var out []byte
+	var err error
+	if OutputFormat == "yaml" {
+		out, err = yaml.Marshal(document)
+	} else {
+		out, err = json.MarshalIndent(document, "", "  ")
+		out = append(out, '\n')
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(out))
+}
+
+// newClusterDocument describes a cluster, along with its image
+func newClusterDocument(cluster *nano.Cluster) clusterDocument {
+	document := clusterDocument{
+		Name:         cluster.Name,
+		State:        cluster.State,
+		Endpoint:     cluster.Endpoint,
+		BindAddress:  cluster.BindAddress,
+		User:         cluster.User,
+		AccessKey:    cluster.AccessKey,
+		SecretKey:    cluster.SecretKey,
+		WorkDir:      cluster.WorkDir,
+		Memory:       cluster.Memory,
+		CPUs:         cluster.CPUs,
+		Size:         cluster.Size,
+		Image:        inspectImage(cluster.ImageID, "tag"),
+		Release:      inspectImage(cluster.ImageID, "release"),
+		ImageCreated: inspectImage(cluster.ImageID, "created"),
+		CnVersion:    cluster.Version,
+		ClonedFrom:   cluster.ClonedFrom,
+	}
+	if !cluster.Created.IsZero() {
+		document.Created = &cluster.Created
+	}
+	return document
+}
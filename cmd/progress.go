@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressBarWidth is the number of characters used to draw the bar itself
+const progressBarWidth = 30
+
+// progressBar draws the progress of a transfer along with its throughput
+// it is written on stderr so it never mixes with the command output
+type progressBar struct {
+	sync.Mutex
+	out      io.Writer
+	name     string
+	total    int64
+	current  int64
+	start    time.Time
+	lastDraw time.Time
+}
+
+// newProgressBar returns a progress bar for a transfer of total bytes
+func newProgressBar(name string, total int64) *progressBar {
+	return &progressBar{
+		out:   os.Stderr,
+		name:  name,
+		total: total,
+		start: time.Now(),
+	}
+}
+
+// Add accounts n more transferred bytes, it is safe to call from several goroutines
+func (p *progressBar) Add(n int64) {
+	p.Lock()
+	defer p.Unlock()
+	p.current += n
+	if p.current > p.total {
+		p.current = p.total
+	}
+	// Redrawing more than a few times per second is useless
+	if time.Since(p.lastDraw) > 200*time.Millisecond || p.current == p.total {
+		p.draw()
+	}
+}
+
+// Read makes the progress bar usable as the Progress reader of the S3 client
+// the client "reads" the bytes it sent, we only count them
+func (p *progressBar) Read(b []byte) (int, error) {
+	p.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Finish draws the final state of the bar and moves to the next line
+func (p *progressBar) Finish() {
+	p.Lock()
+	defer p.Unlock()
+	p.draw()
+	fmt.Fprintln(p.out)
+}
+
+// draw renders the bar, the caller must hold the lock
+func (p *progressBar) draw() {
+	p.lastDraw = time.Now()
+	percent := 100
+	if p.total > 0 {
+		percent = int(p.current * 100 / p.total)
+	}
+	filled := percent * progressBarWidth / 100
+
+	var throughput int64
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		throughput = int64(float64(p.current) / elapsed)
+	}
+
+	fmt.Fprintf(p.out, "\r%s [%s%s] %3d%% %s / %s %s/s   ",
+		p.name,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		percent, formatSize(p.current), formatSize(p.total), formatSize(throughput))
+}
@@ -2,9 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 
-	"github.com/docker/docker/api/types"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +17,9 @@ var (
 
 	// DeleteAll also deletes the container image
 	DeleteAll bool
+
+	// KeepData keeps the named volumes of a persistent cluster
+	KeepData bool
 )
 
 // CliClusterPurge is the Cobra CLI call
@@ -31,6 +34,7 @@ func CliClusterPurge() *cobra.Command {
 	cmd.Flags().SortFlags = false
 	cmd.Flags().BoolVar(&IamSure, "yes-i-am-sure", false, "YES I know what I'm doing and I want to purge")
 	cmd.Flags().BoolVar(&DeleteAll, "all", false, "This also deletes the container image")
+	cmd.Flags().BoolVar(&KeepData, "keep-data", false, "Keep the data of a cluster started with --persist, starting it again brings it back")
 	cmd.Flags().BoolVar(&Help, "help", false, "help for purge")
 
 	return cmd
@@ -48,28 +52,23 @@ func purgeNano(cmd *cobra.Command, args []string) {
 	}
 	notExistCheck(ContainerName)
 	fmt.Println("Purging cluster " + ContainerNameToShow + "...")
-	removeContainer(ContainerName)
-}
-
-func removeContainer(ContainerName string) {
-	if DeleteAll {
-		ImageName = dockerInspect(ContainerName, "image")
-	}
-	options := types.ContainerRemoveOptions{
-		RemoveLinks:   false,
-		RemoveVolumes: true,
-		Force:         true,
+	cluster := getCluster(ContainerName)
+	if KeepData {
+		if !cluster.Persist {
+			log.Fatal("Cluster " + ContainerNameToShow + " does not persist its data, only clusters started with --persist can keep it.")
+		}
+		if err := cluster.PurgeKeepData(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("The data of cluster " + ContainerNameToShow + " is kept, 'cn cluster start " + ContainerNameToShow + "' brings it back.")
+	} else if err := cluster.Purge(); err != nil {
+		log.Fatal(err)
 	}
-	// we don't necessarily want to catch errors here
-	// it's not an issue if the container does not exist
-	getDocker().ContainerRemove(ctx, ContainerName, options)
 
 	if DeleteAll {
-		options := types.ImageRemoveOptions{
-			Force:         true,
-			PruneChildren: true,
+		fmt.Println("Removing container image " + cluster.Image + "...")
+		if err := getRuntime().RemoveImage(ctx, cluster.Image); err != nil {
+			log.Fatal(err)
 		}
-		fmt.Println("Removing container image" + ImageName + "...")
-		getDocker().ImageRemove(ctx, ImageName, options)
 	}
 }
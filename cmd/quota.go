@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdQuota = &cobra.Command{
+		Use:   "quota [command] [arg]",
+		Short: "Manage the quotas of the S3 users and buckets of a cluster",
+		Args:  cobra.NoArgs,
+	}
+
+	// QuotaUserName selects the quota of a user
+	QuotaUserName string
+
+	// QuotaBucketName selects the quota of a bucket
+	QuotaBucketName string
+)
+
+func init() {
+	cmdQuota.AddCommand(
+		CliQuotaSet(),
+		CliQuotaGet(),
+		CliQuotaClear(),
+	)
+}
+
+// addQuotaTargetFlags adds the flags selecting the quota of a user or of a bucket
+func addQuotaTargetFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&QuotaUserName, "user", "", "UID of the user whose quota it is")
+	cmd.Flags().StringVar(&QuotaBucketName, "bucket", "", "Bucket whose quota it is")
+}
+
+// quotaTarget returns the scope and name of the quota selected with --user or --bucket
+func quotaTarget() (string, string) {
+	switch {
+	case QuotaUserName != "" && QuotaBucketName != "":
+		log.Fatal(errors.New("--user and --bucket can not be used together"))
+	case QuotaUserName != "":
+		return nano.QuotaUser, QuotaUserName
+	case QuotaBucketName != "":
+		return nano.QuotaBucket, QuotaBucketName
+	}
+	log.Fatal(errors.New("please select a quota with --user or --bucket"))
+	return "", ""
+}
+
+// formatQuotaLimit describes a limit of a quota, negative limits are no limit
+func formatQuotaLimit(limit int64, format func(int64) string) string {
+	if limit < 0 {
+		return "unlimited"
+	}
+	return format(limit)
+}
+
+// formatCount formats a number of objects
+func formatCount(count int64) string {
+	return strconv.FormatInt(count, 10)
+}
+
+// newQuotaDocument describes the quota of a user or bucket
+func newQuotaDocument(scope string, name string, usage nano.QuotaUsage) quotaDocument {
+	return quotaDocument{
+		Scope:      scope,
+		Name:       name,
+		Enabled:    usage.Enabled,
+		MaxSize:    usage.MaxSize,
+		MaxObjects: usage.MaxObjects,
+		Size:       usage.Size,
+		Objects:    usage.Objects,
+	}
+}
+
+// describeQuota describes the quota of a user or bucket along with its usage, on a single line
+func describeQuota(quota quotaDocument) string {
+	status := "disabled"
+	if quota.Enabled {
+		status = "enabled"
+	}
+	return "Quota of " + quota.Scope + " " + quota.Name + " (" + status + "): " +
+		formatSize(quota.Size) + " of " + formatQuotaLimit(quota.MaxSize, formatSize) + ", " +
+		formatCount(quota.Objects) + " of " + formatQuotaLimit(quota.MaxObjects, formatCount) + " objects"
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliQuotaClear is the Cobra CLI call
+func CliQuotaClear() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear CLUSTER --user USER | --bucket BUCKET",
+		Short: "Remove the limits of the quota of a user or bucket and disable it",
+		Args:  cobra.ExactArgs(1),
+		Run:   clearQuota,
+	}
+	addQuotaTargetFlags(cmd)
+
+	return cmd
+}
+
+// clearQuota removes the quota of a user or bucket
+func clearQuota(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	scope, name := quotaTarget()
+	if err := getCluster(ContainerName).ClearQuota(ctx, scope, name); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Quota of " + scope + " " + name + " cleared.")
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliQuotaGet is the Cobra CLI call
+func CliQuotaGet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get CLUSTER --user USER | --bucket BUCKET",
+		Short: "Print the quota of a user or bucket with its usage",
+		Args:  cobra.ExactArgs(1),
+		Run:   getQuota,
+	}
+	addQuotaTargetFlags(cmd)
+
+	return cmd
+}
+
+// getQuota prints the quota of a user or bucket
+func getQuota(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	scope, name := quotaTarget()
+	usage, err := getCluster(ContainerName).GetQuota(ctx, scope, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	document := newQuotaDocument(scope, name, usage)
+	if structuredOutput() {
+		printDocument(document)
+		return
+	}
+	fmt.Println(describeQuota(document))
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// QuotaMaxSize is the size limit of a quota
+	QuotaMaxSize string
+
+	// QuotaMaxObjects is the object count limit of a quota
+	QuotaMaxObjects int64
+)
+
+// CliQuotaSet is the Cobra CLI call
+func CliQuotaSet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set CLUSTER --user USER | --bucket BUCKET",
+		Short: "Set and enable the quota of a user or bucket",
+		Long: "Set and enable the quota of a user or bucket, the limits not given are kept as they are. \n" +
+			"Writes going over the quota fail with a QuotaExceeded error.",
+		Args: cobra.ExactArgs(1),
+		Run:  setQuota,
+		Example: "cn quota set mycluster --user nano --max-size 1G \n" +
+			"cn quota set mycluster --bucket uploads --max-objects 1000 \n" +
+			"cn quota set mycluster --bucket uploads --max-size unlimited",
+	}
+	cmd.Flags().SortFlags = false
+	addQuotaTargetFlags(cmd)
+	cmd.Flags().StringVar(&QuotaMaxSize, "max-size", "", "Size limit (e.g: 1G), 'unlimited' removes it")
+	cmd.Flags().Int64Var(&QuotaMaxObjects, "max-objects", 0, "Object count limit, -1 removes it")
+
+	return cmd
+}
+
+// setQuota sets the quota of a user or bucket
+func setQuota(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	scope, name := quotaTarget()
+	if !cmd.Flags().Changed("max-size") && !cmd.Flags().Changed("max-objects") {
+		log.Fatal(errors.New("please give a limit with --max-size or --max-objects"))
+	}
+
+	cluster := getCluster(ContainerName)
+	usage, err := cluster.GetQuota(ctx, scope, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	quota := usage.Quota
+	quota.Enabled = true
+	if cmd.Flags().Changed("max-size") {
+		quota.MaxSize = -1
+		if QuotaMaxSize != "unlimited" {
+			if quota.MaxSize, err = parseSize(QuotaMaxSize); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	if cmd.Flags().Changed("max-objects") {
+		quota.MaxObjects = QuotaMaxObjects
+	}
+	if err := cluster.SetQuota(ctx, scope, name, quota); err != nil {
+		log.Fatal(err)
+	}
+	usage.Quota = quota
+	fmt.Println(describeQuota(newQuotaDocument(scope, name, usage)))
+}
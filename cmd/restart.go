@@ -1,8 +1,8 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -15,19 +15,31 @@ func CliClusterRestart() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Run:   restartNano,
 	}
+	addWaitFlags(cmd)
 
 	return cmd
 }
 
 // restartNano restarts Ceph Nano
 func restartNano(cmd *cobra.Command, args []string) {
+	defer cancelOnInterrupt()()
 	ContainerName := ContainerNamePrefix + args[0]
 	ContainerNameToShow := ContainerName[len(ContainerNamePrefix):]
 
 	notExistCheck(ContainerName)
-	fmt.Println("Restarting cluster " + ContainerNameToShow + "...")
-	if err := getDocker().ContainerRestart(ctx, ContainerName, nil); err != nil {
-		log.Fatal(err)
+	fmt.Fprintln(infoOutput(), "Restarting cluster "+ContainerNameToShow+"...")
+	cluster := getCluster(ContainerName)
+	if err := cluster.RestartContainer(ctx); err != nil {
+		checkHealthError(err)
 	}
-	echoInfo(ContainerName)
+	if !shouldWait() {
+		echoStarting(cluster)
+		return
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, WaitTimeout)
+	defer cancel()
+	if err := cluster.WaitProgress(waitCtx, infoOutput()); err != nil {
+		checkHealthError(err)
+	}
+	echoInfo(cluster)
 }
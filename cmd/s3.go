@@ -12,9 +12,13 @@ var (
 	}
 	// S3CmdForce means force operation
 	S3CmdForce bool
+
+	// S3User is the user, or 'UID:NAME' subuser, the S3 commands act as
+	S3User string
 )
 
 func init() {
+	cmdS3.PersistentFlags().StringVar(&S3User, "user", "", "S3 user, or UID:NAME subuser, to act as (default the user of the cluster)")
 	cmdS3.AddCommand(
 		CliS3CmdMb(),
 		CliS3CmdRb(),
@@ -27,5 +31,10 @@ func init() {
 		CliS3CmdInfo(),
 		CliS3CmdCp(),
 		CliS3CmdMv(),
-		CliS3CmdSync())
+		CliS3CmdSync(),
+		CliS3CmdPresign(),
+		CliS3CmdVersioning(),
+		CliS3CmdLifecycle(),
+		CliS3CmdPolicy(),
+		CliS3CmdACL())
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// S3CmdCannedACL is the canned ACL to apply
+	S3CmdCannedACL string
+
+	// S3CmdGrants are the grants to add, as PERMISSION=GRANTEE
+	S3CmdGrants []string
+
+	// S3CmdRevokes are the grantees whose grants are removed
+	S3CmdRevokes []string
+)
+
+// CliS3CmdACL is the Cobra CLI call
+func CliS3CmdACL() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "acl CLUSTER BUCKET[/OBJECT]",
+		Short: "Show or change the ACL of a bucket or object",
+		Long: "Show the ACL of a bucket or object, or change it with a canned ACL or grants. \n" +
+			"A grant is PERMISSION=GRANTEE: the permission is read, write, read-acp, write-acp or full-control, \n" +
+			"the grantee the UID of a user of the cluster, all-users (anonymous requests included) or authenticated-users.",
+		Args: cobra.ExactArgs(2),
+		Run:  S3CmdACL,
+		Example: "cn s3 acl mycluster mybucket \n" +
+			"cn s3 acl mycluster mybucket/myobject --canned public-read \n" +
+			"cn s3 acl mycluster mybucket --grant read=alice --grant write=alice \n" +
+			"cn s3 acl mycluster mybucket --revoke alice",
+	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&S3CmdCannedACL, "canned", "", "Canned ACL replacing the grants: private, public-read, public-read-write or authenticated-read")
+	cmd.Flags().StringArrayVar(&S3CmdGrants, "grant", nil, "Grant to add, as PERMISSION=GRANTEE, can be repeated")
+	cmd.Flags().StringArrayVar(&S3CmdRevokes, "revoke", nil, "Grantee whose grants are removed, can be repeated")
+
+	return cmd
+}
+
+// S3CmdACL shows or changes the ACL of a bucket or object
+func S3CmdACL(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	bucketName, objectName := splitBucketObject(args[1])
+	if S3CmdCannedACL != "" && (len(S3CmdGrants) > 0 || len(S3CmdRevokes) > 0) {
+		log.Fatal("--canned replaces every grant, it can not be used with --grant or --revoke.")
+	}
+	endpoint := getS3Endpoint(ContainerName)
+
+	if S3CmdCannedACL != "" {
+		checkS3Error(endpoint.SetACL(ctx, bucketName, objectName, S3CmdCannedACL))
+	}
+	if len(S3CmdGrants) > 0 || len(S3CmdRevokes) > 0 {
+		acl, err := endpoint.GetACLGrants(ctx, bucketName, objectName)
+		checkS3Error(err)
+		for _, revoke := range S3CmdRevokes {
+			acl.Grants = revokeGrants(acl.Grants, parseGrantee(revoke))
+		}
+		for _, value := range S3CmdGrants {
+			grant, err := parseGrant(value)
+			if err != nil {
+				log.Fatal(err)
+			}
+			acl.Grants = append(acl.Grants, grant)
+		}
+		checkS3Error(endpoint.SetACLGrants(ctx, bucketName, objectName, acl))
+	}
+
+	acl, err := endpoint.GetACLGrants(ctx, bucketName, objectName)
+	checkS3Error(err)
+	canned, err := endpoint.GetACL(ctx, bucketName, objectName)
+	checkS3Error(err)
+	printACL(s3URL(bucketName, objectName), canned, acl)
+}
+
+// parseGrant parses a PERMISSION=GRANTEE grant, e.g: read=alice or full-control=all-users
+func parseGrant(value string) (nano.Grant, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nano.Grant{}, errors.New("invalid grant " + value + ", the format is PERMISSION=GRANTEE (e.g: read=alice)")
+	}
+	permission := strings.ToUpper(strings.Replace(parts[0], "-", "_", -1))
+	return nano.Grant{Grantee: parseGrantee(parts[1]), Permission: permission}, nil
+}
+
+// parseGrantee returns the grantee of a grant, all-users and authenticated-users are the groups
+func parseGrantee(grantee string) string {
+	switch grantee {
+	case "all-users":
+		return nano.GroupAllUsers
+	case "authenticated-users":
+		return nano.GroupAuthenticatedUsers
+	}
+	return grantee
+}
+
+// revokeGrants removes the grants given to a grantee
+func revokeGrants(grants []nano.Grant, grantee string) []nano.Grant {
+	var kept []nano.Grant
+	for _, grant := range grants {
+		if grant.Grantee != grantee {
+			kept = append(kept, grant)
+		}
+	}
+	return kept
+}
+
+// printACL prints the owner and grants of a bucket or object
+func printACL(url string, canned string, acl *nano.AccessControlList) {
+	if structuredOutput() {
+		document := s3ACLDocument{URL: url, Owner: acl.Owner, Canned: canned, Grants: []s3GrantDocument{}}
+		for _, grant := range acl.Grants {
+			document.Grants = append(document.Grants, s3GrantDocument{Grantee: grant.Grantee, Group: grant.IsGroup(), Permission: grant.Permission})
+		}
+		printDocument(document)
+		return
+	}
+	fmt.Println(url + ":")
+	fmt.Printf("   Owner:     %s\n", acl.Owner)
+	fmt.Printf("   Canned:    %s\n", canned)
+	for _, grant := range acl.Grants {
+		fmt.Printf("   Grant:     %s %s\n", grant.Grantee, grant.Permission)
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/ceph/cn/nano"
+	"github.com/minio/minio-go"
+)
+
+// getS3Endpoint returns the Rados Gateway of a given cluster with the keys of the user of --user if any
+func getS3Endpoint(ContainerName string) *nano.S3Endpoint {
+	cluster := getCluster(ContainerName)
+	var endpoint *nano.S3Endpoint
+	var err error
+	if S3User == "" {
+		endpoint, err = cluster.S3Endpoint(ctx)
+	} else {
+		endpoint, err = cluster.UserS3Endpoint(ctx, S3User)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return endpoint
+}
+
+// getS3Client returns an S3 client talking to the Rados Gateway of a given cluster, as the user of --user if any
+func getS3Client(ContainerName string) *minio.Client {
+	s3Client, err := getS3Endpoint(ContainerName).Client()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s3Client
+}
+
+// splitBucketObject splits a 'BUCKET/OBJECT' argument into its bucket and object parts
+// the object part is empty when only a bucket is given
+func splitBucketObject(BucketObjectName string) (string, string) {
+	BucketObjectName = strings.TrimPrefix(BucketObjectName, "s3://")
+	parts := strings.SplitN(BucketObjectName, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// s3URL returns the s3:// representation of a bucket and an object
+func s3URL(bucketName string, objectName string) string {
+	return "s3://" + bucketName + "/" + objectName
+}
+
+// s3VersionURL returns the s3:// representation of a version of an object, the latest one when versionID is empty
+func s3VersionURL(bucketName string, objectName string, versionID string) string {
+	if versionID == "" {
+		return s3URL(bucketName, objectName)
+	}
+	return s3URL(bucketName, objectName) + "?versionId=" + versionID
+}
+
+// checkS3Error exits with the S3 error code returned by the gateway, if any
+func checkS3Error(err error) {
+	if err == nil {
+		return
+	}
+	if s3Err := minio.ToErrorResponse(err); s3Err.Code != "" {
+		log.Fatal("ERROR: S3 error: " + s3Err.Code + ": " + s3Err.Error())
+	}
+	log.Fatal(err)
+}
@@ -2,8 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 )
 
@@ -20,14 +20,35 @@ func CliS3CmdCp() *cobra.Command {
 	return cmd
 }
 
-// S3CmdCp wraps s3cmd command in the container
+// S3CmdCp copies an object server side
 func S3CmdCp(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
 
-	command := []string{"s3cmd", "cp", "s3://" + args[1], "s3://" + args[2]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	source, destination := copyS3Object(getS3Client(ContainerName), args[1], args[2])
+	if structuredOutput() {
+		printDocument(s3TransferDocument{Action: "copy", Source: source, Destination: destination})
+		return
+	}
+	fmt.Println("remote copy: '" + source + "' -> '" + destination + "'")
+}
+
+// copyS3Object copies 'BUCKET1/OBJECT1' to 'BUCKET2/OBJECT2' without downloading it
+// it returns the s3:// URLs of the source and the destination
+func copyS3Object(s3Client *minio.Client, source string, destination string) (string, string) {
+	srcBucketName, srcObjectName := splitBucketObject(source)
+	dstBucketName, dstObjectName := splitBucketObject(destination)
+
+	// Like s3cmd, copying to a bucket keeps the object name
+	if dstObjectName == "" {
+		dstObjectName = srcObjectName
+	}
+
+	dst, err := minio.NewDestinationInfo(dstBucketName, dstObjectName, nil, nil)
+	checkS3Error(err)
+	err = s3Client.CopyObject(dst, minio.NewSourceInfo(srcBucketName, srcObjectName, nil))
+	checkS3Error(err)
+	return s3URL(srcBucketName, srcObjectName), s3URL(dstBucketName, dstObjectName)
 }
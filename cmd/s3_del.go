@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -10,6 +9,9 @@ import (
 var (
 	// S3CmdRec is the option to apply when trying to delete content
 	S3CmdRec bool
+
+	// S3CmdVersionID is the version of the object to act on, the latest when empty
+	S3CmdVersionID string
 )
 
 // CliS3CmdDel is the Cobra CLI call
@@ -17,34 +19,38 @@ func CliS3CmdDel() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "del CLUSTER BUCKET/OBJECT",
 		Short: "Delete file from bucket",
+		Long:  "Delete file from bucket. \n" +
+			"In a versioned bucket, a delete marker hides the object; deleting a version with --version-id removes it for good.",
 		Args:  cobra.ExactArgs(2),
 		Run:   S3CmdDel,
 		DisableFlagsInUseLine: true,
 	}
+	cmd.Flags().StringVar(&S3CmdVersionID, "version-id", "", "Version of the object to delete, see 'cn s3 ls --versions'")
 	//cmd.Flags().BoolVarP(&S3CmdRec, "recursive", "r", false, "Recursive removal.")
 	//cmd.Flags().BoolVarP(&S3CmdForce, "force", "f", false, "Force removal.")
 
 	return cmd
 }
 
-// S3CmdDel wraps s3cmd command in the container
+// S3CmdDel deletes an object from a bucket
 func S3CmdDel(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
+	bucketName, objectName := splitBucketObject(args[1])
 
-	/*
-		S3CmdOpt = "--verbose"
-			if S3CmdRec {
-				S3CmdOpt = S3CmdOpt + " --recursive"
-			}
-			if S3CmdForce {
-				S3CmdOpt = S3CmdOpt + " --force"
-			}
-			command := []string{"s3cmd", "del", S3CmdOpt, "s3://" + args[0]}
-	*/
-	command := []string{"s3cmd", "del", "s3://" + args[1]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	var err error
+	if S3CmdVersionID != "" {
+		err = getS3Endpoint(ContainerName).RemoveObjectVersion(ctx, bucketName, objectName, S3CmdVersionID)
+	} else {
+		err = getS3Client(ContainerName).RemoveObject(bucketName, objectName)
+	}
+	checkS3Error(err)
+	source := s3VersionURL(bucketName, objectName, S3CmdVersionID)
+	if structuredOutput() {
+		printDocument(s3TransferDocument{Action: "delete", Source: source})
+		return
+	}
+	fmt.Println("delete: '" + source + "'")
 }
@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
+	"log"
 
+	"github.com/ceph/cn/nano"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +13,7 @@ func CliS3CmdDu() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "du CLUSTER BUCKET/PREFIX",
 		Short: "Disk usage by buckets",
+		Long:  "Disk usage by buckets, along with the enabled quotas of the bucket and of its owner.",
 		Args:  cobra.ExactArgs(2),
 		Run:   S3CmdDu,
 		DisableFlagsInUseLine: true,
@@ -20,13 +22,49 @@ func CliS3CmdDu() *cobra.Command {
 	return cmd
 }
 
-// S3CmdDu wraps s3cmd command in the container
+// S3CmdDu sums the size of the objects under a bucket or a prefix
 func S3CmdDu(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	command := []string{"s3cmd", "du", "s3://" + args[1]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	bucketName, prefix := splitBucketObject(args[1])
+
+	var totalSize int64
+	objectCount := 0
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for object := range getS3Client(ContainerName).ListObjectsV2(bucketName, prefix, true, doneCh) {
+		checkS3Error(object.Err)
+		totalSize += object.Size
+		objectCount++
+	}
+	quotas := bucketQuotas(getCluster(ContainerName), bucketName)
+	if structuredOutput() {
+		printDocument(s3UsageDocument{Bucket: bucketName, Prefix: prefix, URL: s3URL(bucketName, prefix), Objects: objectCount, Size: totalSize, Quotas: quotas})
+		return
+	}
+	fmt.Printf("%d %5d objects %s\n", totalSize, objectCount, s3URL(bucketName, prefix))
+	for _, quota := range quotas {
+		fmt.Println(describeQuota(quota))
+	}
+}
+
+// bucketQuotas returns the enabled quotas applying to a bucket: its own and the one of its owner
+func bucketQuotas(cluster *nano.Cluster, bucketName string) []quotaDocument {
+	owner, err := cluster.BucketOwner(ctx, bucketName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var quotas []quotaDocument
+	for _, target := range [][2]string{{nano.QuotaBucket, bucketName}, {nano.QuotaUser, owner}} {
+		usage, err := cluster.GetQuota(ctx, target[0], target[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if usage.Enabled {
+			quotas = append(quotas, newQuotaDocument(target[0], target[1], usage))
+		}
+	}
+	return quotas
 }
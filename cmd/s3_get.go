@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 )
 
@@ -14,11 +20,8 @@ var (
 	// S3CmdSkip means do not do anything when object exists
 	S3CmdSkip bool
 
-	// S3CmdContinue means
+	// S3CmdContinue means resuming a partial download or an interrupted upload
 	S3CmdContinue bool
-
-	// S3CmdOpt is the option to apply when trying to get a file and the destination already exists
-	S3CmdOpt string
 )
 
 // CliS3CmdGet is the Cobra CLI call
@@ -34,51 +37,152 @@ func CliS3CmdGet() *cobra.Command {
 	cmd.Flags().SortFlags = false
 	cmd.Flags().BoolVarP(&S3CmdSkip, "skip", "s", true, "Skip over files that exist at the destination")
 	cmd.Flags().BoolVarP(&S3CmdForce, "force", "f", false, "Force overwrite files that exist at the destination")
-	cmd.Flags().BoolVarP(&S3CmdContinue, "continue", "c", false, "Continue getting a partially downloaded file")
+	cmd.Flags().BoolVarP(&S3CmdContinue, "continue", "c", false, "Continue a download that was interrupted, as long as the object did not change")
+	cmd.Flags().StringVar(&S3CmdVersionID, "version-id", "", "Version of the object to get, see 'cn s3 ls --versions'")
 
 	return cmd
 }
 
-// S3CmdGet wraps s3cmd command in the container
+// S3CmdGet streams an object out of a bucket into a local file
 func S3CmdGet(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	BucketObjectName := args[1]
-	var fileName string
+	bucketName, objectName := splitBucketObject(args[1])
 
-	if len(args) > 1 {
+	// Without destination, the object lands in the current directory
+	fileName := path.Base(objectName)
+	if len(args) > 2 {
 		fileName = args[2]
-	} else {
-		fileName = BucketObjectName
+		if info, err := os.Stat(fileName); err == nil && info.IsDir() {
+			fileName = filepath.Join(fileName, path.Base(objectName))
+		}
 	}
 
-	BucketObjectNameBase := path.Base(BucketObjectName)
-
-	if S3CmdForce {
-		S3CmdOpt = "--force"
-	} else if S3CmdSkip {
-		S3CmdOpt = "--skip-existing"
-	} else if S3CmdContinue {
-		S3CmdOpt = "--continue"
+	if S3CmdVersionID != "" {
+		getS3ObjectVersion(ContainerName, bucketName, objectName, fileName)
+		return
 	}
-	// if args
-	command := []string{"s3cmd", "get", S3CmdOpt, "s3://" + BucketObjectName, TempPath}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
 
-	dir := dockerInspect(ContainerName, "Binds")
-	if fileName != BucketObjectName {
-		//if _, err := os.Stat(fileName); os.Stat.Mode.IsDir(err) {
-		if info, err := os.Stat(fileName); err == nil && info.IsDir() {
-			fileName = fileName + "/" + BucketObjectNameBase
+	// Stat first so a missing object is reported before touching the local file
+	s3Client := getS3Client(ContainerName)
+	objectInfo, err := s3Client.StatObject(bucketName, objectName, minio.StatObjectOptions{})
+	checkS3Error(err)
+
+	etag := strings.Trim(objectInfo.ETag, "\"")
+	opts := minio.GetObjectOptions{}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if info, err := os.Stat(fileName); err == nil && !S3CmdForce {
+		if S3CmdContinue {
+			if checkPartialDownload(fileName, info, objectInfo.Size, etag) {
+				printSkip(s3URL(bucketName, objectName), fileName, "'"+fileName+"' is already fully downloaded")
+				return
+			}
+			opts.SetRange(info.Size(), 0)
+			// The rest must come from the same object, even if it is replaced meanwhile
+			opts.SetMatchETag(etag)
+			flags = os.O_WRONLY | os.O_APPEND
+		} else if S3CmdSkip {
+			printSkip(s3URL(bucketName, objectName), fileName, "'"+fileName+"' already exists, use --force to overwrite it or --continue to resume the download")
+			return
 		}
-		_, err := copyFile(dir+"/"+BucketObjectNameBase, fileName)
-		if err != nil {
-			log.Fatal(err)
+	}
+
+	object, err := s3Client.GetObject(bucketName, objectName, opts)
+	checkS3Error(err)
+	defer object.Close()
+	// Until the download is complete, the ETag of the object is kept next to the file for --continue
+	if err := ioutil.WriteFile(downloadETagFile(fileName), []byte(etag), 0644); err != nil {
+		log.Fatal(err)
+	}
+	saveS3Object(object, s3URL(bucketName, objectName), fileName, flags)
+	os.Remove(downloadETagFile(fileName))
+}
+
+// downloadETagFile returns the file holding the ETag of the object being downloaded into fileName
+func downloadETagFile(fileName string) string {
+	return fileName + ".cn-etag"
+}
+
+// checkPartialDownload tells if a local file is the complete download of an object, otherwise it is a part of it to resume
+// a file that is larger, comes from another object or was not downloaded by cn is refused, --force downloads it again
+func checkPartialDownload(fileName string, info os.FileInfo, size int64, etag string) bool {
+	recorded, err := ioutil.ReadFile(downloadETagFile(fileName))
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	switch {
+	case info.Size() > size:
+		log.Fatal("'" + fileName + "' is larger than the object, it is not a part of it. Use --force to download it again.")
+	case err == nil && string(recorded) != etag:
+		log.Fatal("The object changed since '" + fileName + "' was partially downloaded. Use --force to download it again.")
+	case info.Size() == size:
+		// Complete downloads do not keep the ETag, it is the md5 sum of the content for non multipart objects
+		if err != nil && !strings.Contains(etag, "-") && fileMD5(fileName) != etag {
+			log.Fatal("'" + fileName + "' differs from the object. Use --force to download it again.")
 		}
+		os.Remove(downloadETagFile(fileName))
+		return true
+	case err != nil:
+		log.Fatal("'" + fileName + "' is not a partial download of the object, there is nothing to resume. Use --force to download it again.")
+	}
+	return false
+}
+
+// fileMD5 returns the hex md5 sum of a local file
+func fileMD5(fileName string) string {
+	localFile, err := os.Open(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer localFile.Close()
+	hash := md5.New()
+	if _, err := io.Copy(hash, localFile); err != nil {
+		log.Fatal(err)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// getS3ObjectVersion downloads a version of an object into a local file
+func getS3ObjectVersion(ContainerName string, bucketName string, objectName string, fileName string) {
+	if S3CmdContinue {
+		log.Fatal("--continue can not be used with --version-id")
+	}
+	source := s3VersionURL(bucketName, objectName, S3CmdVersionID)
+	if _, err := os.Stat(fileName); err == nil && !S3CmdForce && S3CmdSkip {
+		printSkip(source, fileName, "'"+fileName+"' already exists, use --force to overwrite it")
+		return
+	}
+
+	object, err := getS3Endpoint(ContainerName).GetObjectVersion(ctx, bucketName, objectName, S3CmdVersionID)
+	checkS3Error(err)
+	defer object.Close()
+	saveS3Object(object, source, fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+}
 
+// saveS3Object writes the content of an object to a local file opened with flags
+func saveS3Object(object io.Reader, source string, fileName string, flags int) {
+	localFile, err := os.OpenFile(fileName, flags, 0644)
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer localFile.Close()
 
-	fmt.Println(output)
+	size, err := io.Copy(localFile, object)
+	checkS3Error(err)
+	if structuredOutput() {
+		printDocument(s3TransferDocument{Action: "download", Source: source, Destination: fileName, Size: size})
+		return
+	}
+	fmt.Printf("download: '%s' -> '%s'  [%d bytes]\n", source, fileName, size)
+}
+
+// printSkip reports a download that did not happen
+func printSkip(source string, destination string, reason string) {
+	if structuredOutput() {
+		printDocument(s3TransferDocument{Action: "skip", Source: source, Destination: destination})
+		return
+	}
+	fmt.Println("skip: " + reason)
 }
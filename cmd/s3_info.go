@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 )
 
@@ -19,13 +21,58 @@ func CliS3CmdInfo() *cobra.Command {
 	return cmd
 }
 
-// S3CmdInfo wraps s3cmd command in the container
+// S3CmdInfo prints information about a bucket or an object
 func S3CmdInfo(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	command := []string{"s3cmd", "info", "s3://" + args[1]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	bucketName, objectName := splitBucketObject(args[1])
+	s3Client := getS3Client(ContainerName)
+
+	if objectName == "" {
+		location, err := s3Client.GetBucketLocation(bucketName)
+		checkS3Error(err)
+		policy, err := s3Client.GetBucketPolicy(bucketName)
+		if minio.ToErrorResponse(err).Code == "NoSuchBucketPolicy" {
+			err = nil
+		}
+		checkS3Error(err)
+		if len(policy) == 0 {
+			policy = "none"
+		}
+		if structuredOutput() {
+			printDocument(s3BucketDocument{Bucket: bucketName, URL: s3URL(bucketName, ""), Location: location, Policy: policy})
+			return
+		}
+		fmt.Println(s3URL(bucketName, "") + " (bucket):")
+		fmt.Printf("   Location:  %s\n", location)
+		fmt.Printf("   Policy:    %s\n", policy)
+		return
+	}
+
+	object, err := s3Client.GetObjectACL(bucketName, objectName)
+	checkS3Error(err)
+	if structuredOutput() {
+		document := newS3ObjectDocument(bucketName, *object)
+		document.ContentType = object.ContentType
+		document.Metadata = object.Metadata
+		printDocument(document)
+		return
+	}
+	fmt.Println(s3URL(bucketName, objectName) + " (object):")
+	fmt.Printf("   File size: %d\n", object.Size)
+	fmt.Printf("   Last mod:  %s\n", object.LastModified.Format("Mon, 02 Jan 2006 15:04:05 GMT"))
+	fmt.Printf("   MIME type: %s\n", object.ContentType)
+	fmt.Printf("   ETag:      %s\n", object.ETag)
+
+	// Metadata also carries the ACLs as 'X-Amz-Acl' and 'X-Amz-Grant-*' entries
+	var keys []string
+	for key := range object.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("   %s: %s\n", key, strings.Join(object.Metadata[key], ", "))
+	}
 }
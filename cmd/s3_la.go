@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,19 +19,45 @@ func CliS3CmdLa() *cobra.Command {
 	return cmd
 }
 
-// S3CmdLa wraps s3cmd command in the container
+// S3CmdLa lists all the objects of all the buckets
 func S3CmdLa(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	command := []string{"s3cmd", "la"}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	if len(output) == 1 {
-		command := []string{"s3cmd", "ls"}
-		output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-		fmt.Println(output)
-	} else {
-		fmt.Println(output)
+	s3Client := getS3Client(ContainerName)
+
+	buckets, err := s3Client.ListBuckets()
+	checkS3Error(err)
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	objectCount := 0
+	documents := []s3ListDocument{}
+	for _, bucket := range buckets {
+		created := bucket.CreationDate
+		document := s3ListDocument{Bucket: bucket.Name, URL: s3URL(bucket.Name, ""), Created: &created, Objects: []s3ObjectDocument{}}
+		for object := range s3Client.ListObjectsV2(bucket.Name, "", true, doneCh) {
+			checkS3Error(object.Err)
+			objectCount++
+			if structuredOutput() {
+				document.Objects = append(document.Objects, newS3ObjectDocument(bucket.Name, object))
+				continue
+			}
+			printS3Object(bucket.Name, object)
+		}
+		documents = append(documents, document)
+	}
+
+	if structuredOutput() {
+		printDocument(documents)
+		return
+	}
+
+	// Nothing to show, at least print the buckets
+	if objectCount == 0 {
+		for _, bucket := range buckets {
+			fmt.Printf("%s  %s\n", bucket.CreationDate.Format("2006-01-02 15:04"), s3URL(bucket.Name, ""))
+		}
 	}
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+// CliS3CmdLifecycle is the Cobra CLI call
+func CliS3CmdLifecycle() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lifecycle CLUSTER BUCKET set FILE|get|rm",
+		Short: "Set, show or remove the lifecycle of a bucket",
+		Long: "Set, show or remove the lifecycle of a bucket. \n" +
+			"FILE holds the lifecycle as the XML of the S3 API or as the JSON of 'aws s3api put-bucket-lifecycle-configuration', \n" +
+			"with expiration, noncurrent version expiration and abort incomplete multipart upload rules. \n" +
+			"Rules count in days, start the cluster with --debug-interval to make them fire in seconds.",
+		Args: cobra.RangeArgs(3, 4),
+		Run:  S3CmdLifecycle,
+		Example: "cn s3 lifecycle mycluster mybucket set lifecycle.json \n" +
+			"cn s3 lifecycle mycluster mybucket get -o json \n" +
+			"cn s3 lifecycle mycluster mybucket rm",
+	}
+
+	return cmd
+}
+
+// S3CmdLifecycle sets, shows or removes the lifecycle of a bucket
+func S3CmdLifecycle(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	bucketName, _ := splitBucketObject(args[1])
+	if (args[2] == "set") != (len(args) == 4) {
+		log.Fatal("Please give a lifecycle file to set, and only to set.")
+	}
+	endpoint := getS3Endpoint(ContainerName)
+
+	switch args[2] {
+	case "set":
+		data, err := ioutil.ReadFile(args[3])
+		if err != nil {
+			log.Fatal(err)
+		}
+		lifecycle, err := nano.ParseLifecycle(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		checkS3Error(endpoint.SetBucketLifecycle(ctx, bucketName, lifecycle))
+		fmt.Fprintf(infoOutput(), "Lifecycle of %s set, %d rule(s).\n", s3URL(bucketName, ""), len(lifecycle.Rules))
+	case "get":
+		lifecycle, err := endpoint.GetBucketLifecycle(ctx, bucketName)
+		checkS3Error(err)
+		printLifecycle(bucketName, lifecycle)
+	case "rm":
+		checkS3Error(endpoint.RemoveBucketLifecycle(ctx, bucketName))
+		fmt.Fprintln(infoOutput(), "Lifecycle of "+s3URL(bucketName, "")+" removed.")
+	default:
+		log.Fatal("Unknown action " + args[2] + ", it is one of set, get or rm.")
+	}
+}
+
+// printLifecycle prints the lifecycle of a bucket as XML, or in the format selected with --output
+// the JSON output can be given back to 'cn s3 lifecycle set'
+func printLifecycle(bucketName string, lifecycle *nano.LifecycleConfiguration) {
+	if lifecycle == nil {
+		if structuredOutput() {
+			printDocument(nano.LifecycleConfiguration{Rules: []nano.LifecycleRule{}})
+			return
+		}
+		fmt.Println("Bucket " + s3URL(bucketName, "") + " has no lifecycle.")
+		return
+	}
+	if structuredOutput() {
+		printDocument(lifecycle)
+		return
+	}
+	out, err := xml.MarshalIndent(lifecycle, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(out))
+}
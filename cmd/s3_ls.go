@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ceph/cn/nano"
+	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 )
 
+// S3CmdVersions lists the versions and delete markers of the objects
+var S3CmdVersions bool
+
 // CliS3CmdLs is the Cobra CLI call
 func CliS3CmdLs() *cobra.Command {
 	cmd := &cobra.Command{
@@ -15,17 +20,104 @@ func CliS3CmdLs() *cobra.Command {
 		Args:  cobra.ExactArgs(2),
 		Run:   S3CmdLs,
 	}
+	cmd.Flags().BoolVar(&S3CmdVersions, "versions", false, "List every version of the objects, along with the delete markers")
 
 	return cmd
 }
 
-// S3CmdLs wraps s3cmd command in the container
+// S3CmdLs lists the objects of a bucket
 func S3CmdLs(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	command := []string{"s3cmd", "ls", "s3://" + args[1]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	bucketName, prefix := splitBucketObject(args[1])
+
+	document := s3ListDocument{Bucket: bucketName, Prefix: prefix, URL: s3URL(bucketName, prefix), Objects: []s3ObjectDocument{}}
+	if S3CmdVersions {
+		versions, err := getS3Endpoint(ContainerName).ListObjectVersions(ctx, bucketName, prefix, false)
+		checkS3Error(err)
+		for _, version := range versions {
+			if structuredOutput() {
+				document.Objects = append(document.Objects, newS3VersionDocument(bucketName, version))
+				continue
+			}
+			printS3Version(bucketName, version)
+		}
+		if structuredOutput() {
+			printDocument(document)
+		}
+		return
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for object := range getS3Client(ContainerName).ListObjectsV2(bucketName, prefix, false, doneCh) {
+		checkS3Error(object.Err)
+		if structuredOutput() {
+			document.Objects = append(document.Objects, newS3ObjectDocument(bucketName, object))
+			continue
+		}
+		printS3Object(bucketName, object)
+	}
+	if structuredOutput() {
+		printDocument(document)
+	}
+}
+
+// newS3ObjectDocument describes an object for the structured outputs
+func newS3ObjectDocument(bucketName string, object minio.ObjectInfo) s3ObjectDocument {
+	document := s3ObjectDocument{
+		Key:  object.Key,
+		URL:  s3URL(bucketName, object.Key),
+		Dir:  strings.HasSuffix(object.Key, "/"),
+		Size: object.Size,
+		ETag: strings.Trim(object.ETag, "\""),
+	}
+	if !object.LastModified.IsZero() {
+		document.LastModified = &object.LastModified
+	}
+	return document
+}
+
+// printS3Object prints an object the same way 's3cmd ls' does
+// prefixes are shown as directories
+func printS3Object(bucketName string, object minio.ObjectInfo) {
+	if strings.HasSuffix(object.Key, "/") {
+		fmt.Printf("%26s   %s\n", "DIR", s3URL(bucketName, object.Key))
+		return
+	}
+	fmt.Printf("%s %10d   %s\n", object.LastModified.Format("2006-01-02 15:04"), object.Size, s3URL(bucketName, object.Key))
+}
+
+// newS3VersionDocument describes a version of an object for the structured outputs
+func newS3VersionDocument(bucketName string, version nano.ObjectVersion) s3ObjectDocument {
+	document := newS3ObjectDocument(bucketName, minio.ObjectInfo{
+		Key:          version.Key,
+		Size:         version.Size,
+		ETag:         version.ETag,
+		LastModified: version.LastModified,
+	})
+	document.VersionID = version.VersionID
+	document.Latest = version.IsLatest
+	document.DeleteMarker = version.DeleteMarker
+	return document
+}
+
+// printS3Version prints a version of an object like printS3Object, followed by its version ID
+// the latest version is starred and delete markers are shown as such
+func printS3Version(bucketName string, version nano.ObjectVersion) {
+	if strings.HasSuffix(version.Key, "/") && version.VersionID == "" {
+		fmt.Printf("%26s   %s\n", "DIR", s3URL(bucketName, version.Key))
+		return
+	}
+	size := fmt.Sprintf("%10d", version.Size)
+	if version.DeleteMarker {
+		size = "    DELETE"
+	}
+	latest := " "
+	if version.IsLatest {
+		latest = "*"
+	}
+	fmt.Printf("%s %s   %s %s %s\n", version.LastModified.Format("2006-01-02 15:04"), size, s3URL(bucketName, version.Key), latest, version.VersionID)
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,13 +19,19 @@ func CliS3CmdMb() *cobra.Command {
 	return cmd
 }
 
-// S3CmdMb wraps s3cmd command in the container
+// S3CmdMb creates a bucket
 func S3CmdMb(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	command := []string{"s3cmd", "mb", "s3://" + args[1]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	bucketName, _ := splitBucketObject(args[1])
+
+	err := getS3Client(ContainerName).MakeBucket(bucketName, "")
+	checkS3Error(err)
+	if structuredOutput() {
+		printDocument(s3BucketDocument{Bucket: bucketName, URL: s3URL(bucketName, ""), Status: "created"})
+		return
+	}
+	fmt.Println("Bucket '" + s3URL(bucketName, "") + "' created")
 }
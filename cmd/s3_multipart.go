@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go"
+)
+
+const (
+	// minPartSize is the smallest part S3 accepts, only the last part can be smaller
+	minPartSize = 5 * 1024 * 1024
+
+	// maxParts is the maximum number of parts of a single upload
+	maxParts = 10000
+)
+
+// multipartPart describes a part of the local file to upload
+type multipartPart struct {
+	number int
+	offset int64
+	size   int64
+}
+
+// multipartUpload uploads a local file in parts of partSize bytes, sending up to parallel parts at once
+// when resume is set, the parts already sent by a pending upload of the same object are not sent again
+// on failure the upload is left pending so it can be resumed
+func multipartUpload(s3Client *minio.Client, fileName string, size int64, bucketName string, objectName string, partSize int64, parallel int, resume bool, progress *progressBar) {
+	s3Core := minio.Core{Client: s3Client}
+
+	allParts := splitParts(size, partSize)
+	if len(allParts) > maxParts {
+		log.Fatalf("%s would need %d parts of %s, S3 allows %d parts at most. Please use a bigger --part-size.", fileName, len(allParts), formatSize(partSize), maxParts)
+	}
+
+	localFile, err := os.Open(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer localFile.Close()
+
+	uploadID := ""
+	uploadedParts := map[int]minio.ObjectPart{}
+	if resume {
+		uploadID = findPendingUpload(s3Core, bucketName, objectName)
+		if uploadID != "" {
+			uploadedParts = listUploadedParts(s3Core, bucketName, objectName, uploadID)
+		}
+	}
+	if uploadID == "" {
+		uploadID, err = s3Core.NewMultipartUpload(bucketName, objectName, minio.PutObjectOptions{})
+		checkS3Error(err)
+	}
+
+	// Only send the parts the gateway does not have yet
+	var completeParts []minio.CompletePart
+	parts := make(chan multipartPart, len(allParts))
+	for _, part := range allParts {
+		if uploaded, ok := uploadedParts[part.number]; ok && uploaded.Size == part.size {
+			md5Sum, _ := partMD5(localFile, part)
+			if hex.EncodeToString(md5Sum) == strings.Trim(uploaded.ETag, "\"") {
+				completeParts = append(completeParts, minio.CompletePart{PartNumber: part.number, ETag: uploaded.ETag})
+				progress.Add(part.size)
+				continue
+			}
+		}
+		parts <- part
+	}
+	close(parts)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var uploadErr error
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Keep going when a part fails, every part sent is one less to send on resume
+			for part := range parts {
+				uploaded, err := uploadPart(s3Core, localFile, bucketName, objectName, uploadID, part, progress)
+				mutex.Lock()
+				if err != nil && uploadErr == nil {
+					uploadErr = err
+				} else if err == nil {
+					completeParts = append(completeParts, minio.CompletePart{PartNumber: part.number, ETag: uploaded.ETag})
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if uploadErr != nil {
+		progress.Finish()
+		fmt.Println("Upload of " + fileName + " interrupted, run the same command with --continue to resume it.")
+		checkS3Error(uploadErr)
+	}
+
+	sort.Slice(completeParts, func(i, j int) bool { return completeParts[i].PartNumber < completeParts[j].PartNumber })
+	_, err = s3Core.CompleteMultipartUpload(bucketName, objectName, uploadID, completeParts)
+	checkS3Error(err)
+}
+
+// splitParts cuts size bytes into parts of partSize bytes, the last part holds the remainder
+func splitParts(size int64, partSize int64) []multipartPart {
+	var parts []multipartPart
+	for offset := int64(0); offset < size; offset += partSize {
+		part := multipartPart{number: len(parts) + 1, offset: offset, size: partSize}
+		if offset+partSize > size {
+			part.size = size - offset
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// uploadPart sends a part of the local file, its md5 sum is checked by the gateway
+// the bytes of the part move the progress bar as they are sent, those of a failed part are taken off again
+func uploadPart(s3Core minio.Core, localFile *os.File, bucketName string, objectName string, uploadID string, part multipartPart, progress *progressBar) (minio.ObjectPart, error) {
+	md5Sum, err := partMD5(localFile, part)
+	if err != nil {
+		return minio.ObjectPart{}, err
+	}
+	reader := &progressReader{SectionReader: io.NewSectionReader(localFile, part.offset, part.size), progress: progress}
+	uploaded, err := s3Core.PutObjectPart(bucketName, objectName, uploadID, part.number,
+		reader, part.size, base64.StdEncoding.EncodeToString(md5Sum), "", nil)
+	if err != nil {
+		progress.Add(-reader.counted)
+	}
+	return uploaded, err
+}
+
+// progressReader adds the bytes read from a part to the progress bar
+// it stays seekable so that the S3 client can retry the part, seeking back takes the bytes to send again off the bar
+type progressReader struct {
+	*io.SectionReader
+	progress *progressBar
+	counted  int64
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.SectionReader.Read(b)
+	r.counted += int64(n)
+	r.progress.Add(int64(n))
+	return n, err
+}
+
+func (r *progressReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.SectionReader.Seek(offset, whence)
+	if err == nil && position < r.counted {
+		r.progress.Add(position - r.counted)
+		r.counted = position
+	}
+	return position, err
+}
+
+// partMD5 computes the md5 sum of a part of the local file
+func partMD5(localFile *os.File, part multipartPart) ([]byte, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(localFile, part.offset, part.size)); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// findPendingUpload returns the ID of the most recent pending upload of an object, if any
+func findPendingUpload(s3Core minio.Core, bucketName string, objectName string) string {
+	var latest minio.ObjectMultipartInfo
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := s3Core.ListMultipartUploads(bucketName, objectName, keyMarker, uploadIDMarker, "", 1000)
+		checkS3Error(err)
+		for _, upload := range result.Uploads {
+			// The listing is done on a prefix, other objects can show up
+			if upload.Key == objectName && upload.Initiated.After(latest.Initiated) {
+				latest = upload
+			}
+		}
+		if !result.IsTruncated {
+			return latest.UploadID
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+// listUploadedParts returns the parts already received for a pending upload, indexed by part number
+func listUploadedParts(s3Core minio.Core, bucketName string, objectName string, uploadID string) map[int]minio.ObjectPart {
+	uploadedParts := map[int]minio.ObjectPart{}
+	partNumberMarker := 0
+	for {
+		result, err := s3Core.ListObjectParts(bucketName, objectName, uploadID, partNumberMarker, 1000)
+		checkS3Error(err)
+		for _, part := range result.ObjectParts {
+			uploadedParts[part.PartNumber] = part
+		}
+		if !result.IsTruncated {
+			return uploadedParts
+		}
+		partNumberMarker = result.NextPartNumberMarker
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,13 +19,21 @@ func CliS3CmdMv() *cobra.Command {
 	return cmd
 }
 
-// S3CmdMv wraps s3cmd command in the container
+// S3CmdMv moves an object, S3 has no rename so this is a copy followed by a delete
 func S3CmdMv(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	command := []string{"s3cmd", "mv", "s3://" + args[1], "s3://" + args[2]}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	s3Client := getS3Client(ContainerName)
+
+	source, destination := copyS3Object(s3Client, args[1], args[2])
+	bucketName, objectName := splitBucketObject(args[1])
+	err := s3Client.RemoveObject(bucketName, objectName)
+	checkS3Error(err)
+	if structuredOutput() {
+		printDocument(s3TransferDocument{Action: "move", Source: source, Destination: destination})
+		return
+	}
+	fmt.Println("move: '" + source + "' -> '" + destination + "'")
 }
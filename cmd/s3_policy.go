@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliS3CmdPolicy is the Cobra CLI call
+func CliS3CmdPolicy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy CLUSTER BUCKET set FILE|get|rm",
+		Short: "Set, show or remove the policy of a bucket",
+		Long: "Set, show or remove the JSON policy of a bucket. \n" +
+			"Policies grant access to other users of the cluster, or to anonymous requests with the \"*\" principal.",
+		Args: cobra.RangeArgs(3, 4),
+		Run:  S3CmdPolicy,
+		Example: "cn s3 policy mycluster mybucket set policy.json \n" +
+			"cn s3 policy mycluster mybucket get \n" +
+			"cn s3 policy mycluster mybucket rm",
+	}
+
+	return cmd
+}
+
+// S3CmdPolicy sets, shows or removes the policy of a bucket
+func S3CmdPolicy(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	bucketName, _ := splitBucketObject(args[1])
+	if (args[2] == "set") != (len(args) == 4) {
+		log.Fatal("Please give a policy file to set, and only to set.")
+	}
+	endpoint := getS3Endpoint(ContainerName)
+
+	switch args[2] {
+	case "set":
+		policy, err := ioutil.ReadFile(args[3])
+		if err != nil {
+			log.Fatal(err)
+		}
+		checkS3Error(endpoint.SetBucketPolicy(ctx, bucketName, policy))
+		fmt.Fprintln(infoOutput(), "Policy of "+s3URL(bucketName, "")+" set.")
+	case "get":
+		policy, err := endpoint.GetBucketPolicy(ctx, bucketName)
+		checkS3Error(err)
+		printPolicy(bucketName, policy)
+	case "rm":
+		checkS3Error(endpoint.RemoveBucketPolicy(ctx, bucketName))
+		fmt.Fprintln(infoOutput(), "Policy of "+s3URL(bucketName, "")+" removed.")
+	default:
+		log.Fatal("Unknown action " + args[2] + ", it is one of set, get or rm.")
+	}
+}
+
+// printPolicy prints the policy of a bucket as indented JSON, or in the format selected with --output
+func printPolicy(bucketName string, policy []byte) {
+	if policy == nil {
+		if structuredOutput() {
+			printDocument(map[string]interface{}{})
+			return
+		}
+		fmt.Println("Bucket " + s3URL(bucketName, "") + " has no policy.")
+		return
+	}
+	if structuredOutput() {
+		var document interface{}
+		if err := json.Unmarshal(policy, &document); err != nil {
+			log.Fatal(err)
+		}
+		printDocument(document)
+		return
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, policy, "", "  "); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(out.String())
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/spf13/cobra"
+)
+
+// presignRegion is the region URLs and policies are signed for, the Rados Gateway accepts any
+const presignRegion = "us-east-1"
+
+// presignMaxExpires is the longest validity of a SigV4 signature
+const presignMaxExpires = 7 * 24 * time.Hour
+
+var (
+	// S3CmdMethod is the HTTP method the presigned URL is valid for
+	S3CmdMethod string
+
+	// S3CmdExpires is how long the presigned URL or POST policy stays valid
+	S3CmdExpires time.Duration
+
+	// S3CmdPost generates a presigned POST policy instead of a URL
+	S3CmdPost bool
+
+	// S3CmdContentType restricts the content type accepted by a POST policy
+	S3CmdContentType string
+
+	// S3CmdMaxSize restricts the size of the object uploaded with a POST policy
+	S3CmdMaxSize string
+
+	// S3CmdStartsWith makes the POST policy accept any key starting with OBJECT
+	S3CmdStartsWith bool
+)
+
+// CliS3CmdPresign is the Cobra CLI call
+func CliS3CmdPresign() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "presign CLUSTER BUCKET/OBJECT",
+		Short: "Generate a presigned URL or POST policy for an object",
+		Long: "Generate a presigned URL or POST policy for an object.\n" +
+			"URLs and policies are signed (SigV4) with the keys of the S3 user of the cluster. \n" +
+			"Anyone holding them can perform the request until they expire, without any credentials.",
+		Args: cobra.ExactArgs(2),
+		Run:  S3CmdPresign,
+		Example: "cn s3 presign mycluster mybucket/myobject \n" +
+			"cn s3 presign mycluster mybucket/myobject --method PUT --expires 1h \n" +
+			"cn s3 presign mycluster mybucket/uploads/ --post --starts-with --max-size 10M --content-type image/png",
+	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVarP(&S3CmdMethod, "method", "m", "GET", "HTTP method the URL is valid for (GET, PUT, HEAD or DELETE)")
+	cmd.Flags().DurationVarP(&S3CmdExpires, "expires", "e", 24*time.Hour, "How long the URL or POST policy stays valid, 7 days at most")
+	cmd.Flags().BoolVar(&S3CmdPost, "post", false, "Generate a POST policy with its form fields, for browser uploads")
+	cmd.Flags().StringVar(&S3CmdContentType, "content-type", "", "POST policy only, content type the upload must have")
+	cmd.Flags().StringVar(&S3CmdMaxSize, "max-size", "", "POST policy only, maximum size of the upload (e.g: 10M)")
+	cmd.Flags().BoolVar(&S3CmdStartsWith, "starts-with", false, "POST policy only, accept any key starting with OBJECT")
+
+	return cmd
+}
+
+// S3CmdPresign prints a presigned URL, or a presigned POST policy and its form fields
+func S3CmdPresign(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	bucketName, objectName := splitBucketObject(args[1])
+	if S3CmdExpires < time.Second || S3CmdExpires > presignMaxExpires {
+		log.Fatal("Invalid expiration " + S3CmdExpires.String() + ", it must be between 1s and 7 days (168h).")
+	}
+
+	if !S3CmdPost {
+		method := strings.ToUpper(S3CmdMethod)
+		switch method {
+		case "GET", "PUT", "HEAD", "DELETE":
+		default:
+			log.Fatal("Unsupported method " + S3CmdMethod + ", please use GET, PUT, HEAD or DELETE.")
+		}
+		if objectName == "" {
+			log.Fatal("Please give an object to presign, the format is BUCKET/OBJECT.")
+		}
+
+		presignedURL, err := getS3Client(ContainerName).Presign(method, bucketName, objectName, S3CmdExpires, nil)
+		checkS3Error(err)
+		if structuredOutput() {
+			printDocument(s3PresignDocument{Method: method, URL: presignedURL.String(), Expires: time.Now().UTC().Add(S3CmdExpires)})
+			return
+		}
+		fmt.Println(presignedURL)
+		return
+	}
+
+	if objectName == "" && !S3CmdStartsWith {
+		log.Fatal("Please give an object to presign or use --starts-with to accept any key of the bucket.")
+	}
+	expires := time.Now().UTC().Add(S3CmdExpires)
+	policy := postPolicy{
+		Bucket:        bucketName,
+		Key:           objectName,
+		KeyStartsWith: S3CmdStartsWith,
+		ContentType:   S3CmdContentType,
+		Expires:       expires,
+	}
+	if S3CmdMaxSize != "" {
+		maxSize, err := parseSize(S3CmdMaxSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+		policy.MaxSize = maxSize
+	}
+
+	endpoint := getS3Endpoint(ContainerName)
+	postURL, formData, err := presignPost(endpoint.URL, endpoint.AccessKey, endpoint.SecretKey, policy)
+	checkS3Error(err)
+
+	// With --starts-with, the key field is up to the uploader, it must keep the prefix
+	if S3CmdStartsWith {
+		formData["key"] = objectName + "${filename}"
+	}
+
+	if structuredOutput() {
+		printDocument(s3PresignDocument{Method: "POST", URL: postURL.String(), Expires: expires, Fields: formData})
+		return
+	}
+
+	var fields []string
+	for field := range formData {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	fmt.Println("POST URL: " + postURL.String())
+	fmt.Println("Form fields:")
+	curl := "curl"
+	for _, field := range fields {
+		fmt.Printf("  %s: %s\n", field, formData[field])
+		curl += fmt.Sprintf(" -F '%s=%s'", field, formData[field])
+	}
+	fmt.Println("Example: " + curl + " -F 'file=@<FILE>' " + postURL.String())
+}
+
+// postPolicy lists what a browser upload (POST) must match to be accepted, see presignPost
+type postPolicy struct {
+	Bucket        string
+	Key           string // key of the object, or its prefix when KeyStartsWith is set
+	KeyStartsWith bool   // accept any key starting with Key, the empty prefix accepts any key of the bucket
+	ContentType   string // any content type when empty
+	MaxSize       int64  // bytes, no limit when 0
+	Expires       time.Time
+}
+
+// presignPost signs a POST policy and returns the URL to post to along with the form fields to send
+// the S3 client refuses an empty key prefix, presignPost builds the policy itself to accept it
+func presignPost(endpoint string, accessKey string, secretKey string, policy postPolicy) (*url.URL, map[string]string, error) {
+	if policy.Bucket == "" {
+		return nil, nil, errors.New("the bucket of a POST policy must be given")
+	}
+	if policy.Key == "" && !policy.KeyStartsWith {
+		return nil, nil, errors.New("the key of a POST policy must be given, or its prefix")
+	}
+	now := time.Now().UTC()
+	if !policy.Expires.After(now) {
+		return nil, nil, errors.New("the expiration of a POST policy must be in the future")
+	}
+	target, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + policy.Bucket + "/")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	form := map[string]string{
+		"bucket":           policy.Bucket,
+		"key":              policy.Key,
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": s3signer.GetCredential(accessKey, presignRegion, now),
+		"x-amz-date":       now.Format("20060102T150405Z"),
+	}
+	conditions := []interface{}{[]string{"eq", "$bucket", policy.Bucket}}
+	if policy.KeyStartsWith {
+		conditions = append(conditions, []string{"starts-with", "$key", policy.Key})
+	} else {
+		conditions = append(conditions, []string{"eq", "$key", policy.Key})
+	}
+	if policy.ContentType != "" {
+		form["Content-Type"] = policy.ContentType
+		conditions = append(conditions, []string{"eq", "$Content-Type", policy.ContentType})
+	}
+	if policy.MaxSize > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", 0, policy.MaxSize})
+	}
+	for _, field := range []string{"x-amz-date", "x-amz-algorithm", "x-amz-credential"} {
+		conditions = append(conditions, []string{"eq", "$" + field, form[field]})
+	}
+
+	document, err := json.Marshal(map[string]interface{}{
+		"expiration": policy.Expires.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	form["policy"] = base64.StdEncoding.EncodeToString(document)
+	form["x-amz-signature"] = s3signer.PostPresignSignatureV4(form["policy"], now, secretKey, presignRegion)
+	return target, form, nil
+}
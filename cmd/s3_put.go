@@ -2,46 +2,103 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// S3CmdPartSize is the size of the parts of a multipart upload
+	S3CmdPartSize = "15MiB"
+
+	// S3CmdParallel is the number of parts uploaded at the same time
+	S3CmdParallel = 4
+
+	// S3CmdNoProgress hides the progress bar
+	S3CmdNoProgress bool
+)
+
 // CliS3CmdPut is the Cobra CLI call
 func CliS3CmdPut() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "put CLUSTER FILE BUCKET",
+		Use:   "put CLUSTER FILE BUCKET[/OBJECT]",
 		Short: "Put file into bucket",
-		Args:  cobra.ExactArgs(3),
-		Run:   S3CmdPut,
-		DisableFlagsInUseLine: true,
+		Long: "Put file into bucket.\n" +
+			"Files bigger than the part size are sent as a multipart upload, several parts at a time. \n" +
+			"If such an upload gets interrupted, run the same command with --continue to only send the missing parts.",
+		Args: cobra.ExactArgs(3),
+		Run:  S3CmdPut,
+		Example: "cn s3 put mycluster /tmp/big.iso mybucket \n" +
+			"cn s3 put mycluster /tmp/big.iso mybucket/isos/ --part-size 64M --parallel 8 \n" +
+			"cn s3 put mycluster /tmp/big.iso mybucket --continue",
 	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&S3CmdPartSize, "part-size", S3CmdPartSize, "Size of the parts of a multipart upload, at least 5M")
+	cmd.Flags().IntVarP(&S3CmdParallel, "parallel", "p", S3CmdParallel, "Number of parts uploaded at the same time")
+	cmd.Flags().BoolVarP(&S3CmdContinue, "continue", "c", false, "Resume an interrupted upload of the same object")
+	cmd.Flags().BoolVar(&S3CmdNoProgress, "no-progress", false, "Do not display the progress bar")
 
 	return cmd
 }
 
-// S3CmdPut wraps s3cmd command in the container
+// S3CmdPut streams a local file into a bucket
 func S3CmdPut(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
-	dir := dockerInspect(ContainerName, "Binds")
 	fileName := args[1]
-	bucketName := args[2]
-	fileNameBase := path.Base(fileName)
+	bucketName, objectName := splitBucketObject(args[2])
+
+	// Like s3cmd, putting into a bucket or a 'directory' keeps the file name
+	if objectName == "" || strings.HasSuffix(objectName, "/") {
+		objectName = objectName + filepath.Base(fileName)
+	}
+
+	size := putS3Object(getS3Client(ContainerName), fileName, bucketName, objectName, !S3CmdNoProgress)
+	if structuredOutput() {
+		printDocument(s3TransferDocument{Action: "upload", Source: fileName, Destination: s3URL(bucketName, objectName), Size: size})
+		return
+	}
+	fmt.Printf("upload: '%s' -> '%s'  [%d bytes]\n", fileName, s3URL(bucketName, objectName), size)
+}
+
+// putS3Object uploads a local file as is, the content is read from the file while being sent
+// files bigger than the part size are sent as a parallel multipart upload
+func putS3Object(s3Client *minio.Client, fileName string, bucketName string, objectName string, showProgress bool) int64 {
+	partSize, err := parseSize(S3CmdPartSize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if partSize < minPartSize {
+		log.Fatal("The part size must be at least " + formatSize(minPartSize) + ".")
+	}
+	if S3CmdParallel < 1 {
+		log.Fatal("At least one part must be uploaded at a time.")
+	}
+
+	info, err := os.Stat(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	if _, err := os.Stat(dir + "/" + fileNameBase); os.IsNotExist(err) {
-		_, err := copyFile(fileName, dir+"/"+fileNameBase)
-		if err != nil {
-			log.Fatal(err)
-		}
+	// The progress bar still counts when hidden, it just writes nowhere
+	progress := newProgressBar(filepath.Base(fileName), info.Size())
+	if !showProgress {
+		progress.out = ioutil.Discard
 	}
 
-	command := []string{"s3cmd", "put", TempPath + fileNameBase, "s3://" + bucketName}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	if info.Size() <= partSize {
+		_, err = s3Client.FPutObject(bucketName, objectName, fileName, minio.PutObjectOptions{Progress: progress})
+		checkS3Error(err)
+	} else {
+		multipartUpload(s3Client, fileName, info.Size(), bucketName, objectName, partSize, S3CmdParallel, S3CmdContinue, progress)
+	}
+	progress.Finish()
+	return info.Size()
 }
@@ -1,17 +1,23 @@
 package cmd
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 )
 
 // CliS3CmdSync is the Cobra CLI call
 func CliS3CmdSync() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "sync CLUSTER LOCAL_DIR BUCKET",
+		Use:   "sync CLUSTER LOCAL_DIR BUCKET[/PREFIX]",
 		Short: "Synchronize a directory tree to S3",
 		Args:  cobra.ExactArgs(3),
 		Run:   S3CmdSync,
@@ -21,29 +27,103 @@ func CliS3CmdSync() *cobra.Command {
 	return cmd
 }
 
-// S3CmdSync wraps s3cmd command in the container
+// S3CmdSync uploads the files of a directory tree that are missing or different in the bucket
 func S3CmdSync(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 
 	notExistCheck(ContainerName)
 	notRunningCheck(ContainerName)
 	localDir := args[1]
-	bucketName := args[2]
-	dir := dockerInspect(ContainerName, "Binds")
-	destDir := TempPath
+	bucketName, prefix := splitBucketObject(args[2])
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	// Like s3cmd, 'dir' is uploaded as 'dir/...' while 'dir/' only uploads its content
+	root := filepath.Clean(localDir)
+	if !strings.HasSuffix(localDir, string(os.PathSeparator)) {
+		root = filepath.Dir(root)
+	}
+
+	fmt.Fprintf(infoOutput(), "Syncing directory '%s' in the '%s' bucket. \n \n", localDir, bucketName)
 
-	if localDir != dir {
-		destDir = dir + "/" + localDir
-		err := copyDir(localDir, destDir)
+	s3Client := getS3Client(ContainerName)
+	document := s3SyncDocument{Source: localDir, Destination: s3URL(bucketName, prefix), Transfers: []s3TransferDocument{}}
+	err := filepath.Walk(localDir, func(fileName string, info os.FileInfo, err error) error {
 		if err != nil {
+			return err
+		}
+		// Symlinks are ignored and skipped.
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relativeName, err := filepath.Rel(root, fileName)
+		if err != nil {
+			return err
+		}
+		objectName := prefix + filepath.ToSlash(relativeName)
+
+		if isS3ObjectInSync(s3Client, fileName, info, bucketName, objectName) {
+			document.Skipped++
+			return nil
+		}
+		size := putS3Object(s3Client, fileName, bucketName, objectName, false)
+		document.Uploaded++
+		if structuredOutput() {
+			document.Transfers = append(document.Transfers, s3TransferDocument{Action: "upload", Source: fileName, Destination: s3URL(bucketName, objectName), Size: size})
+			return nil
+		}
+		fmt.Printf("upload: '%s' -> '%s'  [%d bytes]\n", fileName, s3URL(bucketName, objectName), size)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if structuredOutput() {
+		printDocument(document)
+		return
+	}
+	fmt.Printf("Done. Uploaded %d file(s), %d file(s) already in sync.\n", document.Uploaded, document.Skipped)
+}
+
+// isS3ObjectInSync tells if an object has the same size and content as a local file
+// the content is compared on the md5 sum of the file, which is the ETag of non multipart objects
+// multipart objects have an ETag made of the md5 sums of their parts followed by the number of parts
+func isS3ObjectInSync(s3Client *minio.Client, fileName string, info os.FileInfo, bucketName string, objectName string) bool {
+	object, err := s3Client.StatObject(bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil || object.Size != info.Size() {
+		return false
+	}
+	etag := strings.Trim(object.ETag, "\"")
+
+	localFile, err := os.Open(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer localFile.Close()
+
+	if !strings.Contains(etag, "-") {
+		hash := md5.New()
+		if _, err := io.Copy(hash, localFile); err != nil {
 			log.Fatal(err)
 		}
+		return hex.EncodeToString(hash.Sum(nil)) == etag
 	}
-	fmt.Printf("Syncing directory '%s' in the '%s' bucket. \n"+
-		"It might take some time depending on the amount of data. \n"+
-		"Do not expect any output until the upload is finished. \n \n", localDir, bucketName)
 
-	command := []string{"s3cmd", "sync", destDir, "s3://" + bucketName}
-	output := strings.TrimSuffix(string(execContainer(ContainerName, command)), "\n") + " on cluster " + ContainerName
-	fmt.Println(output)
+	// We can only tell for objects uploaded with the current part size
+	partSize, err := parseSize(S3CmdPartSize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	parts := splitParts(info.Size(), partSize)
+	hash := md5.New()
+	for _, part := range parts {
+		md5Sum, err := partMD5(localFile, part)
+		if err != nil {
+			log.Fatal(err)
+		}
+		hash.Write(md5Sum)
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(hash.Sum(nil)), len(parts)) == etag
 }
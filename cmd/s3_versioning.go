@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliS3CmdVersioning is the Cobra CLI call
+func CliS3CmdVersioning() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "versioning CLUSTER BUCKET enable|suspend|status",
+		Short: "Enable, suspend or show the versioning of a bucket",
+		Long: "Enable, suspend or show the versioning of a bucket. \n" +
+			"Once enabled, versioning can only be suspended: the versions already kept stay until deleted with 'cn s3 del --version-id'.",
+		Args:      cobra.ExactArgs(3),
+		ValidArgs: []string{"enable", "suspend", "status"},
+		Run:       S3CmdVersioning,
+		Example: "cn s3 versioning mycluster mybucket enable \n" +
+			"cn s3 versioning mycluster mybucket status",
+	}
+
+	return cmd
+}
+
+// S3CmdVersioning enables, suspends or shows the versioning of a bucket
+func S3CmdVersioning(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	bucketName, _ := splitBucketObject(args[1])
+	endpoint := getS3Endpoint(ContainerName)
+
+	switch args[2] {
+	case "enable", "suspend":
+		checkS3Error(endpoint.SetBucketVersioning(ctx, bucketName, args[2] == "enable"))
+	case "status":
+	default:
+		log.Fatal("Unknown action " + args[2] + ", it is one of enable, suspend or status.")
+	}
+
+	status, err := endpoint.GetBucketVersioning(ctx, bucketName)
+	checkS3Error(err)
+	if status == "" {
+		status = "Disabled"
+	}
+	if structuredOutput() {
+		printDocument(s3VersioningDocument{Bucket: bucketName, URL: s3URL(bucketName, ""), Status: status})
+		return
+	}
+	fmt.Println("Versioning of " + s3URL(bucketName, "") + " is " + status)
+}
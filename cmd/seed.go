@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+// CliClusterSeed is the Cobra CLI call
+func CliClusterSeed() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "seed NAME MANIFEST",
+		Short: "Create buckets and objects declared in a manifest",
+		Long: "Create buckets and objects declared in a YAML manifest.\n" +
+			"Buckets can enable versioning and get a canned ACL, objects come from a local file or inline content \n" +
+			"and can have a content type, metadata, tags and a canned ACL. \n" +
+			"Seeding is idempotent: existing buckets are kept and objects are only uploaded again when they changed.",
+		Args: cobra.ExactArgs(2),
+		Run:  seedNano,
+		Example: "cn cluster seed mycluster fixtures.yaml \n" +
+			"cn cluster start mycluster --seed fixtures.yaml",
+	}
+	return cmd
+}
+
+// seedNano applies a manifest to a cluster
+func seedNano(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	manifest, err := nano.LoadManifest(args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	cluster := getCluster(ContainerName)
+	waitCluster(cluster)
+
+	if err := cluster.Seed(ctx, manifest); err != nil {
+		log.Fatal(err)
+	}
+
+	objects := 0
+	for _, bucket := range manifest.Buckets {
+		objects += len(bucket.Objects)
+	}
+	fmt.Printf("Cluster %s seeded with %d bucket(s) and %d object(s) from %s\n", cluster.Name, len(manifest.Buckets), objects, args[1])
+}
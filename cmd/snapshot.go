@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// SnapshotForce replaces an existing snapshot with the same tag
+	SnapshotForce bool
+)
+
+// CliClusterSnapshot is the Cobra CLI call
+func CliClusterSnapshot() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot NAME TAG",
+		Short: "Save the data of a cluster to restore it later",
+		Long: "Save the configuration and data of a cluster under TAG, 'cn cluster restore' brings it back in seconds.\n" +
+			"A running cluster is stopped while its data is copied, then started again. \n" +
+			"Snapshots are stored in ~/.local/share/cn/snapshots.",
+		Args: cobra.ExactArgs(2),
+		Run:  snapshotNano,
+		Example: "cn cluster snapshot mycluster clean \n" +
+			"cn cluster restore mycluster clean",
+	}
+	cmd.Flags().BoolVar(&SnapshotForce, "force", false, "Replace an existing snapshot with the same tag")
+
+	return cmd
+}
+
+// CliClusterRestore is the Cobra CLI call
+func CliClusterRestore() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore NAME TAG",
+		Short: "Bring a cluster back to a snapshot",
+		Long: "Bring a cluster back to a snapshot taken with 'cn cluster snapshot'.\n" +
+			"Everything written since the snapshot is lost.",
+		Args:    cobra.ExactArgs(2),
+		Run:     restoreNano,
+		Example: "cn cluster restore mycluster clean",
+	}
+
+	return cmd
+}
+
+// snapshotNano takes a snapshot of a cluster
+func snapshotNano(cmd *cobra.Command, args []string) {
+	defer cancelOnInterrupt()()
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	dir := snapshotDir(args[0], args[1])
+	if _, err := os.Stat(dir); err == nil && !SnapshotForce {
+		log.Fatal("Snapshot " + args[1] + " of cluster " + args[0] + " already exists, use --force to replace it.")
+	}
+
+	fmt.Fprintln(infoOutput(), "Taking snapshot "+args[1]+" of cluster "+args[0]+"...")
+	cluster := getCluster(ContainerName)
+	if err := cluster.Snapshot(ctx, dir); err != nil {
+		checkHealthError(err)
+	}
+	fmt.Println("Snapshot " + args[1] + " of cluster " + args[0] + " saved in " + dir)
+}
+
+// restoreNano restores a snapshot of a cluster
+func restoreNano(cmd *cobra.Command, args []string) {
+	defer cancelOnInterrupt()()
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	dir := snapshotDir(args[0], args[1])
+	if _, err := os.Stat(dir); err != nil {
+		log.Fatal("Cluster " + args[0] + " has no snapshot " + args[1] + ".")
+	}
+
+	fmt.Fprintln(infoOutput(), "Restoring snapshot "+args[1]+" of cluster "+args[0]+"...")
+	cluster := getCluster(ContainerName)
+	if err := cluster.Restore(ctx, dir); err != nil {
+		checkHealthError(err)
+	}
+	echoInfo(cluster)
+}
+
+// snapshotDir returns the directory holding a snapshot of a cluster
+func snapshotDir(name string, tag string) string {
+	if tag == "" || strings.ContainsAny(tag, `/\`) || strings.HasPrefix(tag, ".") {
+		log.Fatal("Invalid snapshot tag '" + tag + "', it must not start with '.' or contain '/'.")
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(homeDir(), ".local", "share")
+	}
+	return filepath.Join(dataHome, "cn", "snapshots", name, tag)
+}
@@ -4,129 +4,181 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/go-connections/nat"
+	"github.com/ceph/cn/nano"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// PrivilegedContainer whether or not the container should run Privileged
 	PrivilegedContainer bool
+
+	// MemorySize is the memory limit of the container
+	MemorySize string
+
+	// CPUCount is the number of CPUs the container can use
+	CPUCount float64
+
+	// OSDSize is the size of the OSD backing store
+	OSDSize string
+
+	// RgwPort pins the port of the S3 gateway
+	RgwPort int
+
+	// RgwPortRange is the range of ports scanned for the S3 gateway
+	RgwPortRange string
+
+	// BindAddress is the host address the S3 gateway is published on
+	BindAddress string
+
+	// PersistData keeps the data of the cluster in named volumes
+	PersistData bool
+
+	// SeedManifest is a manifest of buckets and objects applied once the cluster is healthy
+	SeedManifest string
+
+	// LifecycleDebugInterval is how long a day of the lifecycle rules lasts, for testing
+	LifecycleDebugInterval time.Duration
+
+	// WaitTimeout bounds the wait for the cluster to be healthy
+	WaitTimeout time.Duration
+
+	// WaitHealthy waits for the cluster to be healthy before returning, --no-wait turns it off
+	WaitHealthy bool
+
+	// NoWait returns as soon as the container is started
+	NoWait bool
 )
 
 // CliClusterStart is the Cobra CLI call
 func CliClusterStart() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "start",
+		Use:   "start [NAME]",
 		Short: "Start object storage server",
-		Args:  cobra.ExactArgs(1),
-		Run:   startNano,
+		Long: "Start object storage server.\n" +
+			"Clusters can be defined in a cn.yaml file of the current directory or in ~/.config/cn/config.yaml, \n" +
+			"flags given on the command line take precedence over their definition. \n" +
+			"Without NAME, every cluster defined in cn.yaml is started.",
+		Args: cobra.MaximumNArgs(1),
+		Run:  startNano,
 		Example: "cn start \n" +
 			"cn start --work-dir /tmp \n" +
-			"cn start --image ceph/daemon:tag-stable-3.0-luminous-ubuntu-16.04",
+			"cn start --image ceph/daemon:tag-stable-3.0-luminous-ubuntu-16.04 \n" +
+			"cn start --memory 2G --cpus 2 --size 20G \n" +
+			"cn start --bind 127.0.0.1 --port 9000 \n" +
+			"cn start --seed fixtures.yaml \n" +
+			"cn start --timeout 5m \n" +
+			"cn start --no-wait",
 	}
 	cmd.Flags().SortFlags = false
-	cmd.Flags().StringVarP(&WorkingDirectory, "work-dir", "d", "/usr/share/ceph-nano", "Directory to work from")
-	cmd.Flags().StringVarP(&ImageName, "image", "i", "ceph/daemon", "USE AT YOUR OWN RISK. Ceph container image to use, format is 'username/image:tag'.")
+	cmd.Flags().StringVarP(&WorkingDirectory, "work-dir", "d", nano.DefaultWorkDir, "Directory to work from")
+	cmd.Flags().StringVarP(&ImageName, "image", "i", nano.DefaultImage, "USE AT YOUR OWN RISK. Ceph container image to use, format is 'username/image:tag'.")
 	cmd.Flags().BoolVar(&PrivilegedContainer, "privileged", false, "Starts the container in privileged mode")
+	cmd.Flags().StringVar(&MemorySize, "memory", "512M", "Memory limit of the container (e.g: 2G), 512M at least")
+	cmd.Flags().Float64Var(&CPUCount, "cpus", nano.DefaultCPUs, "Number of CPUs the container can use, fractions are allowed (e.g: 1.5)")
+	cmd.Flags().StringVar(&OSDSize, "size", "", "Size of the OSD backing store (e.g: 20G), the image default when empty")
+	cmd.Flags().IntVar(&RgwPort, "port", 0, "Port of the S3 gateway, fails if it is busy (default the first free port of --port-range)")
+	cmd.Flags().StringVar(&RgwPortRange, "port-range", fmt.Sprintf("%d-%d", nano.DefaultMinPort, nano.DefaultMaxPort), "Range of ports scanned for a free one")
+	cmd.Flags().StringVar(&BindAddress, "bind", nano.DefaultBindAddress, "Host address the S3 gateway is published on, 127.0.0.1 keeps it local")
+	cmd.Flags().BoolVar(&PersistData, "persist", false, "Keep the data in named volumes, so it survives 'cn cluster purge --keep-data'")
+	cmd.Flags().StringVar(&SeedManifest, "seed", "", "Manifest of buckets and objects to create once the cluster is healthy, see 'cn cluster seed'")
+	cmd.Flags().DurationVar(&LifecycleDebugInterval, "debug-interval", 0, "Make a day of the lifecycle rules last this long (e.g: 10s), to test them in seconds")
+	addWaitFlags(cmd)
 	cmd.Flags().BoolVar(&Help, "help", false, "help for start")
 
 	return cmd
 }
 
 // startNano starts Ceph Nano
+// without a name, the clusters defined in the project config file are started
 func startNano(cmd *cobra.Command, args []string) {
-	// Test for a leftover container
-	// Usually happens when someone fails to run the container on an exposed directory
-	// Typical error on Docker For Mac you will see:
-	// panic: Error response from daemon: Mounts denied:
-	// The path /usr/share/ceph-nano is not shared from OS X and is not known to Docker.
-	// You can configure shared paths from Docker -> Preferences... -> File Sharing.
-	ContainerName := ContainerNamePrefix + args[0]
-	ContainerNameToShow := ContainerName[len(ContainerNamePrefix):]
-
-	if status := containerStatus(ContainerName, true, "created"); status {
-		removeContainer(ContainerName)
-	}
-
-	if status := containerStatus(ContainerName, false, "running"); status {
-		fmt.Println("Cluster " + ContainerNameToShow + " is already running!")
-	} else if status := containerStatus(ContainerName, true, "exited"); status {
-		fmt.Println("Starting cluster " + ContainerNameToShow + "...")
-		startContainer(ContainerName)
-	} else {
-		pullImage()
-		fmt.Println("Running cluster " + ContainerNameToShow + "...")
-		runContainer(cmd, args)
-	}
-	echoInfo(ContainerName)
-}
-
-// runContainer creates a new container when nothing exists
-func runContainer(cmd *cobra.Command, args []string) {
-	ContainerName := ContainerNamePrefix + args[0]
-	RgwPort := generateRGWPortToUse()
-	if RgwPort == "notfound" {
-		log.Fatal("Unable to find a port between 8000 and 8100.")
+	defer cancelOnInterrupt()()
+	clusters, projectClusters := loadConfig()
+	if len(args) == 1 {
+		startCluster(cmd, args[0], clusters[args[0]])
+		return
 	}
-	RgwNatPort := RgwPort + "/tcp"
 
-	exposedPorts := nat.PortSet{
-		nat.Port(RgwNatPort): {},
+	if len(projectClusters) == 0 {
+		fmt.Println("Please give the name of the cluster to start, or define clusters in " + ConfigFile + ". \n")
+		cmd.Help()
+		os.Exit(1)
 	}
-
-	portBindings := nat.PortMap{
-		nat.Port(RgwNatPort): []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: RgwPort,
-			},
-		},
-	}
-
-	envs := []string{
-		"RGW_CIVETWEB_PORT=" + RgwPort, // DON'T TOUCH MY POSITION IN THE SLICE OR YOU WILL BREAK dockerInspect()
-		"DEBUG=verbose",
-		"CEPH_DEMO_UID=" + CephNanoUID,
-		"NETWORK_AUTO_DETECT=4",
-		"CEPH_DAEMON=demo",
-		"DEMO_DAEMONS=mon,mgr,osd,rgw"}
-
-	ressources := container.Resources{
-		Memory:   536870912, // 512MB
-		NanoCPUs: 1,
+	for _, name := range projectClusters {
+		startCluster(cmd, name, clusters[name])
 	}
+}
 
-	config := &container.Config{
-		Image:        ImageName,
-		Hostname:     ContainerName + "-faa32aebf00b",
-		ExposedPorts: exposedPorts,
-		Env:          envs,
-		Volumes: map[string]struct{}{
-			"/etc/ceph":     struct{}{},
-			"/var/lib/ceph": struct{}{},
-		},
+// startCluster starts a cluster, flags given on the command line take precedence over its config
+func startCluster(cmd *cobra.Command, name string, config clusterConfig) {
+	memory, err := parseSize(configOrFlag(cmd, "memory", config.Memory, MemorySize))
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	hostConfig := &container.HostConfig{
-		PortBindings: portBindings,
-		Binds:        []string{WorkingDirectory + ":" + TempPath},
-		Resources:    ressources,
-		Privileged:   PrivilegedContainer,
+	var size int64
+	if osdSize := configOrFlag(cmd, "size", config.Size, OSDSize); osdSize != "" {
+		if size, err = parseSize(osdSize); err != nil {
+			log.Fatal(err)
+		}
 	}
-
-	resp, err := getDocker().ContainerCreate(ctx, config, hostConfig, nil, ContainerName)
+	minPort, maxPort, err := parsePortRange(configOrFlag(cmd, "port-range", config.PortRange, RgwPortRange))
 	if err != nil {
 		log.Fatal(err)
 	}
+	cpus := CPUCount
+	if !cmd.Flags().Changed("cpus") && config.CPUs != 0 {
+		cpus = config.CPUs
+	}
+	port := RgwPort
+	if !cmd.Flags().Changed("port") && config.Port != 0 {
+		port = config.Port
+	}
+	privileged := PrivilegedContainer
+	if !cmd.Flags().Changed("privileged") {
+		privileged = config.Privileged
+	}
+	persist := PersistData
+	if !cmd.Flags().Changed("persist") {
+		persist = config.Persist
+	}
+	debugInterval := LifecycleDebugInterval
+	if !cmd.Flags().Changed("debug-interval") && config.DebugInterval != "" {
+		if debugInterval, err = time.ParseDuration(config.DebugInterval); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var manifest *nano.Manifest
+	if seed := configOrFlag(cmd, "seed", config.Seed, SeedManifest); seed != "" {
+		if manifest, err = nano.LoadManifest(seed); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	err = getDocker().ContainerStart(ctx, resp.ID, types.ContainerStartOptions{})
-	// The if removes the error:
-	//panic: runtime error: invalid memory address or nil pointer dereference
-	//[signal SIGSEGV: segmentation violation code=0x1 addr=0x20 pc=0x137a2b4]
+	cluster, err := nano.Start(ctx, nano.Options{
+		Name:                   name,
+		Image:                  configOrFlag(cmd, "image", config.Image, ImageName),
+		WorkDir:                configOrFlag(cmd, "work-dir", config.WorkDir, WorkingDirectory),
+		Privileged:             privileged,
+		Persist:                persist,
+		Memory:                 memory,
+		CPUs:                   cpus,
+		Size:                   size,
+		BindAddress:            configOrFlag(cmd, "bind", config.Bind, BindAddress),
+		Port:                   port,
+		MinPort:                minPort,
+		MaxPort:                maxPort,
+		Users:                  config.nanoUsers(),
+		Buckets:                config.Buckets,
+		Seed:                   manifest,
+		LifecycleDebugInterval: debugInterval,
+		Timeout:                WaitTimeout,
+		NoWait:                 !shouldWait(),
+		Runtime:                getRuntime(),
+		Progress:               infoOutput(),
+	})
 	if err != nil {
 		if strings.Contains(err.Error(), "Mounts denied") {
 			fmt.Println("ERROR: It looks like you need to use the --work-dir option. \n" +
@@ -137,15 +189,58 @@ func runContainer(cmd *cobra.Command, args []string) {
 				"On Docker for Mac / Windows, shared directories can be found in the settings.")
 			cmd.Help()
 			os.Exit(1)
-		} else {
-			log.Fatal(err)
 		}
+		checkHealthError(err)
 	}
+	if !shouldWait() {
+		echoStarting(cluster)
+		return
+	}
+	echoInfo(cluster)
 }
 
-// startContainer starts a container that is stopped
-func startContainer(ContainerName string) {
-	if err := getDocker().ContainerStart(ctx, ContainerName, types.ContainerStartOptions{}); err != nil {
-		log.Fatal(err)
+// addWaitFlags adds the flags controlling the wait for a cluster to be healthy
+func addWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&WaitTimeout, "timeout", nano.DefaultTimeout, "How long to wait for the cluster to be healthy, cn exits with code 124 after it")
+	cmd.Flags().BoolVar(&WaitHealthy, "wait", true, "Wait for the cluster to be healthy, showing its startup phases")
+	cmd.Flags().BoolVar(&NoWait, "no-wait", false, "Return as soon as the container is started, same as --wait=false")
+}
+
+// shouldWait tells if the cluster must be waited for
+func shouldWait() bool {
+	return WaitHealthy && !NoWait
+}
+
+// echoStarting tells about a cluster which was not waited for
+func echoStarting(cluster *nano.Cluster) {
+	if structuredOutput() {
+		printDocument(newClusterDocument(cluster))
+		return
+	}
+	fmt.Println("Cluster " + cluster.Name + " is starting, 'cn cluster wait " + cluster.Name + "' waits for it to be ready.")
+}
+
+// configOrFlag returns the value of a flag given on the command line, else the config value if any, else the flag default
+func configOrFlag(cmd *cobra.Command, flag string, configValue string, flagValue string) string {
+	if !cmd.Flags().Changed(flag) && configValue != "" {
+		return configValue
+	}
+	return flagValue
+}
+
+// parsePortRange parses a 'FIRST-LAST' port range
+func parsePortRange(portRange string) (int, int, error) {
+	parts := strings.SplitN(portRange, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid port range %q, the format is FIRST-LAST (e.g: 8000-8100)", portRange)
+	}
+	minPort, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q, the format is FIRST-LAST (e.g: 8000-8100)", portRange)
+	}
+	maxPort, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q, the format is FIRST-LAST (e.g: 8000-8100)", portRange)
 	}
+	return minPort, maxPort, nil
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -25,17 +24,17 @@ func CliClusterStop() *cobra.Command {
 func stopNano(cmd *cobra.Command, args []string) {
 	ContainerName := ContainerNamePrefix + args[0]
 	ContainerNameToShow := ContainerName[len(ContainerNamePrefix):]
-	timeout := 5 * time.Second
+	state := containerState(ContainerName)
 
-	if status := containerStatus(ContainerName, true, "exited"); status {
+	if state == "exited" {
 		fmt.Println("Cluster " + ContainerNameToShow + " is already stopped.")
 		os.Exit(0)
-	} else if status := containerStatus(ContainerName, false, "running"); !status {
+	} else if state != "running" {
 		fmt.Println("Cluster " + ContainerNameToShow + " does not exist yet.")
 		os.Exit(0)
 	} else {
 		fmt.Println("Stopping cluster " + ContainerNameToShow + "...")
-		if err := getDocker().ContainerStop(ctx, ContainerName, &timeout); err != nil {
+		if err := getCluster(ContainerName).Stop(); err != nil {
 			log.Fatal(err)
 		}
 	}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// UpgradeImage is the image a cluster is upgraded to
+	UpgradeImage string
+)
+
+// CliClusterUpgrade is the Cobra CLI call
+func CliClusterUpgrade() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade NAME",
+		Short: "Recreate a cluster on another image, keeping its data",
+		Long: "Recreate a cluster on another image, keeping its data, port, work dir and resources.\n" +
+			"The image is pulled first. If the cluster is not healthy on the new image, it is rolled back to its previous one.",
+		Args: cobra.ExactArgs(1),
+		Run:  upgradeNano,
+		Example: "cn cluster upgrade mycluster \n" +
+			"cn cluster upgrade mycluster --image ceph/daemon:latest-octopus",
+	}
+	cmd.Flags().StringVarP(&UpgradeImage, "image", "i", nano.DefaultImage, "Ceph container image to upgrade to, format is 'username/image:tag'")
+
+	return cmd
+}
+
+// upgradeNano upgrades a cluster to another image
+func upgradeNano(cmd *cobra.Command, args []string) {
+	defer cancelOnInterrupt()()
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	cluster := getCluster(ContainerName)
+	if err := cluster.Upgrade(ctx, UpgradeImage, infoOutput()); err != nil {
+		if upgradeErr, ok := err.(*nano.UpgradeError); ok && upgradeErr.RollbackErr == nil {
+			fmt.Fprintln(infoOutput(), "Cluster "+cluster.Name+" was rolled back to image "+nano.ShortImageID(upgradeErr.PreviousImageID)+".")
+			if healthErr, ok := upgradeErr.Err.(*nano.HealthError); ok {
+				fmt.Fprintln(infoOutput(), "Logs of the failed upgrade:")
+				fmt.Fprintln(infoOutput(), healthErr.Logs)
+			}
+		}
+		log.Fatal(err)
+	}
+	echoInfo(cluster)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdUser = &cobra.Command{
+		Use:   "user [command] [arg]",
+		Short: "Manage the S3 users of a cluster",
+		Long: "Manage the S3 users of a cluster with radosgw-admin. \n" +
+			"A USER written 'UID:NAME' is a subuser of UID, with keys of its own.",
+		Args: cobra.NoArgs,
+	}
+)
+
+func init() {
+	cmdUser.AddCommand(
+		CliUserCreate(),
+		CliUserList(),
+		CliUserInfo(),
+		CliUserRemove(),
+		CliUserKeyRotate(),
+		CliUserSuspend(),
+		CliUserEnable(),
+	)
+}
+
+// newUserDocument describes a user along with its subusers
+func newUserDocument(user nano.User) userDocument {
+	document := userDocument{
+		UID:         user.UID,
+		DisplayName: user.DisplayName,
+		Suspended:   user.Suspended,
+		AccessKey:   user.AccessKey,
+		SecretKey:   user.SecretKey,
+		Subusers:    []subuserDocument{},
+	}
+	for _, subuser := range user.Subusers {
+		document.Subusers = append(document.Subusers, subuserDocument{
+			ID:          subuser.ID,
+			Permissions: subuser.Permissions,
+			AccessKey:   subuser.AccessKey,
+			SecretKey:   subuser.SecretKey,
+		})
+	}
+	return document
+}
+
+// userStatus describes whether a user is suspended
+func userStatus(user nano.User) string {
+	if user.Suspended {
+		return "suspended"
+	}
+	return "active"
+}
+
+// echoUser prints a user along with its keys and subusers
+func echoUser(user nano.User) {
+	if structuredOutput() {
+		printDocument(newUserDocument(user))
+		return
+	}
+
+	InfoLine :=
+		"\nS3 user is: " + user.UID + " (" + userStatus(user) + ")\n" +
+			"Display name is: " + user.DisplayName + "\n" +
+			"S3 access key is: " + user.AccessKey + "\n" +
+			"S3 secret key is: " + user.SecretKey + "\n"
+	for _, subuser := range user.Subusers {
+		InfoLine += "Subuser " + subuser.ID + " (" + subuser.Permissions + ") access key is: " + subuser.AccessKey + "\n" +
+			"Subuser " + subuser.ID + " (" + subuser.Permissions + ") secret key is: " + subuser.SecretKey + "\n"
+	}
+	fmt.Println(InfoLine)
+}
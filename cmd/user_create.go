@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// UserDisplayName is the display name of a new user
+	UserDisplayName string
+
+	// UserAccessKey and UserSecretKey are the S3 keys of a new user, generated when empty
+	UserAccessKey string
+	UserSecretKey string
+
+	// SubuserAccess is what a new subuser is allowed to do
+	SubuserAccess string
+)
+
+// CliUserCreate is the Cobra CLI call
+func CliUserCreate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create CLUSTER USER",
+		Short: "Create an S3 user, or a subuser written UID:NAME",
+		Long: "Create an S3 user, its keys are generated unless given. \n" +
+			"USER written UID:NAME creates a subuser of UID with generated keys and the permissions of --access.",
+		Args: cobra.ExactArgs(2),
+		Run:  createUser,
+		Example: "cn user create mycluster alice \n" +
+			"cn user create mycluster bob --access-key BOBKEY --secret-key BOBSECRET \n" +
+			"cn user create mycluster alice:readonly --access read",
+	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&UserDisplayName, "display-name", "", "Display name of the user (default its UID)")
+	cmd.Flags().StringVar(&UserAccessKey, "access-key", "", "S3 access key of the user, generated when empty")
+	cmd.Flags().StringVar(&UserSecretKey, "secret-key", "", "S3 secret key of the user, generated when empty")
+	cmd.Flags().StringVar(&SubuserAccess, "access", "full", "Permissions of a subuser: read, write, readwrite or full")
+
+	return cmd
+}
+
+// createUser creates an S3 user or subuser
+func createUser(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	cluster := getCluster(ContainerName)
+
+	var user nano.User
+	var err error
+	if strings.Contains(args[1], ":") {
+		user, err = cluster.CreateSubuser(ctx, args[1], SubuserAccess)
+	} else {
+		if _, err := cluster.GetUser(ctx, args[1]); err == nil {
+			log.Fatal("User " + args[1] + " already exists on cluster " + args[0] + ", see 'cn user info'.")
+		}
+		user, err = cluster.CreateUser(ctx, nano.User{
+			UID:         args[1],
+			DisplayName: UserDisplayName,
+			AccessKey:   UserAccessKey,
+			SecretKey:   UserSecretKey,
+		})
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	echoUser(user)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliUserInfo is the Cobra CLI call
+func CliUserInfo() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info CLUSTER USER",
+		Short: "Print an S3 user with its keys and subusers",
+		Args:  cobra.ExactArgs(2),
+		Run:   infoUser,
+	}
+	return cmd
+}
+
+// infoUser prints an S3 user
+func infoUser(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	user, err := getCluster(ContainerName).GetUser(ctx, args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	echoUser(user)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliUserKeyRotate is the Cobra CLI call
+func CliUserKeyRotate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key-rotate CLUSTER USER",
+		Short: "Replace the S3 keys of a user, or of a subuser written UID:NAME",
+		Long: "Replace the S3 keys of a user or subuser with generated ones, the previous keys stop working. \n" +
+			"Rotating the keys of the user of the cluster updates the keys 'cn s3' uses too.",
+		Args: cobra.ExactArgs(2),
+		Run:  rotateUserKey,
+	}
+	return cmd
+}
+
+// rotateUserKey replaces the S3 keys of a user or subuser
+func rotateUserKey(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	user, err := getCluster(ContainerName).RotateKey(ctx, args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	echoUser(user)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/apcera/termtables"
+	"github.com/spf13/cobra"
+)
+
+// CliUserList is the Cobra CLI call
+func CliUserList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls CLUSTER",
+		Short: "Print the S3 users of a cluster",
+		Args:  cobra.ExactArgs(1),
+		Run:   listUsers,
+	}
+	return cmd
+}
+
+// listUsers prints the S3 users of a cluster
+func listUsers(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	users, err := getCluster(ContainerName).ListUsers(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if structuredOutput() {
+		documents := []userDocument{}
+		for _, user := range users {
+			documents = append(documents, newUserDocument(user))
+		}
+		printDocument(documents)
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.AddHeaders("UID", "DISPLAY NAME", "STATUS", "SUBUSERS", "ACCESS KEY")
+	for _, user := range users {
+		table.AddRow(user.UID, user.DisplayName, userStatus(user), strconv.Itoa(len(user.Subusers)), user.AccessKey)
+	}
+	fmt.Println(table.Render())
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// UserPurgeData removes the buckets and objects of a user along with it
+var UserPurgeData bool
+
+// CliUserRemove is the Cobra CLI call
+func CliUserRemove() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm CLUSTER USER",
+		Short: "Remove an S3 user, or a subuser written UID:NAME",
+		Long: "Remove an S3 user along with its subusers, or a single subuser written UID:NAME. \n" +
+			"A user owning buckets is only removed with --purge-data, which removes its buckets too.",
+		Args: cobra.ExactArgs(2),
+		Run:  removeUser,
+	}
+	cmd.Flags().BoolVar(&UserPurgeData, "purge-data", false, "Remove the buckets and objects of the user too")
+
+	return cmd
+}
+
+// removeUser removes an S3 user or subuser
+func removeUser(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	if err := getCluster(ContainerName).RemoveUser(ctx, args[1], UserPurgeData); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("User " + args[1] + " removed from cluster " + args[0] + ".")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// CliUserSuspend is the Cobra CLI call
+func CliUserSuspend() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "suspend CLUSTER USER",
+		Short: "Suspend an S3 user, its requests are denied",
+		Args:  cobra.ExactArgs(2),
+		Run:   suspendUser,
+	}
+	return cmd
+}
+
+// CliUserEnable is the Cobra CLI call
+func CliUserEnable() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "enable CLUSTER USER",
+		Short: "Enable an S3 user suspended before",
+		Args:  cobra.ExactArgs(2),
+		Run:   enableUser,
+	}
+	return cmd
+}
+
+// suspendUser suspends an S3 user
+func suspendUser(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	if err := getCluster(ContainerName).SuspendUser(ctx, args[1]); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("User " + args[1] + " of cluster " + args[0] + " is suspended.")
+}
+
+// enableUser enables a suspended S3 user
+func enableUser(cmd *cobra.Command, args []string) {
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	notRunningCheck(ContainerName)
+	if err := getCluster(ContainerName).EnableUser(ctx, args[1]); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("User " + args[1] + " of cluster " + args[0] + " is enabled.")
+}
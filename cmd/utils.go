@@ -1,27 +1,27 @@
 package cmd
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
-	"net"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
-	"time"
 
-	"github.com/docker/docker/api/types"
+	"github.com/ceph/cn/nano"
 	"github.com/jmoiron/jsonq"
 )
 
+// exit codes of the commands waiting for a cluster, telling a slow cluster from a broken one
+const (
+	exitTimeout     = 124 // as timeout(1)
+	exitInterrupted = 130 // as shells do on SIGINT
+)
+
 // validateEnv verifies the ability to run the program
 func validateEnv() {
 	seLinux()
@@ -44,149 +44,6 @@ func seLinux() {
 	}
 }
 
-// byLastOctetValue implements sort.Interface used in sorting a list
-// of ip address by their last octet value.
-type byLastOctetValue []net.IP
-
-func (n byLastOctetValue) Len() int      { return len(n) }
-func (n byLastOctetValue) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
-func (n byLastOctetValue) Less(i, j int) bool {
-	return []byte(n[i].To4())[3] < []byte(n[j].To4())[3]
-}
-
-// getInterfaceIPv4s is synonymous to net.InterfaceAddrs()
-// returns net.IP IPv4 only representation of the net.Addr.
-// Additionally the returned list is sorted by their last
-// octet value.
-//
-// [The logic to sort by last octet is implemented to
-// prefer CIDRs with higher octets, this in-turn skips the
-// localhost/loopback address to be not preferred as the
-// first ip on the list. Subsequently this list helps us print
-// a user friendly message with appropriate values].
-func getInterfaceIPv4s() ([]net.IP, error) {
-	addrs, err := net.InterfaceAddrs()
-	if err != nil {
-		return nil, fmt.Errorf("Unable to determine network interface address. %s", err)
-	}
-	// Go through each return network address and collate IPv4 addresses.
-	var nips []net.IP
-	for _, addr := range addrs {
-		if addr.Network() == "ip+net" {
-			var nip net.IP
-			// Attempt to parse the addr through CIDR.
-			nip, _, err = net.ParseCIDR(addr.String())
-			if err != nil {
-				return nil, fmt.Errorf("Unable to parse address %s, error %s", addr, err)
-			}
-			// Collect only IPv4 addrs.
-			if nip.To4() != nil {
-				nips = append(nips, nip)
-			}
-		}
-	}
-	// Sort the list of IPs by their last octet value.
-	sort.Sort(sort.Reverse(byLastOctetValue(nips)))
-	return nips, nil
-}
-
-// execContainer execs a given command inside the container
-func execContainer(ContainerName string, cmd []string) []byte {
-	optionsCreate := types.ExecConfig{
-		AttachStdout: true,
-		AttachStderr: true,
-		Cmd:          cmd,
-	}
-
-	response, err := getDocker().ContainerExecCreate(ctx, ContainerName, optionsCreate)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	optionsAttach := types.ExecStartCheck{
-		Detach: false,
-		Tty:    false,
-	}
-	connection, err := getDocker().ContainerExecAttach(ctx, response.ID, optionsAttach)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	defer connection.Close()
-	output, err := ioutil.ReadAll(connection.Reader)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Remove 8 first characters to get a readable content
-	// Sometimes the command returns nothing, without the following if the program fails without
-	// runtime error: slice bounds out of range
-	if len(output) > 0 {
-		return output[8:]
-	}
-	return nil
-}
-
-// grepForSuccess searches for the word 'SUCCESS' inside the container logs
-func grepForSuccess(ContainerName string) bool {
-	out, err := getDocker().ContainerLogs(ctx, ContainerName, types.ContainerLogsOptions{ShowStdout: true})
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(out)
-	newStr := buf.String()
-
-	if strings.Contains(newStr, "SUCCESS") {
-		return true
-	}
-	return false
-}
-
-// cephNanoHealth loops on grepForSuccess for 30 seconds, fails after.
-func cephNanoHealth(ContainerName string) {
-	// setting timeout values
-	timeout := 60
-	poll := 0
-
-	// wait for 60sec to validate that the container started properly
-	for poll < timeout {
-		if grepForSuccess(ContainerName) {
-			return
-		}
-		time.Sleep(time.Second * 1)
-		poll++
-	}
-
-	// if we reach here, something is broken in the container
-	fmt.Println("The container " + ContainerName + " never reached a clean state. Showing the container logs now:")
-	// ideally we would return the second value of GrepForSuccess when it's false
-	// this would mean having 2 return values for GrepForSuccess
-	out, err := getDocker().ContainerLogs(ctx, ContainerName, types.ContainerLogsOptions{ShowStdout: true})
-	if err != nil {
-		log.Fatal(err)
-	}
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(out)
-	newStr := buf.String()
-	fmt.Println(newStr)
-	log.Fatal("Please open an issue at: https://github.com/ceph/cn with the logs above.")
-}
-
-// curlTestURL tests a given URL
-func curlTestURL(url string) bool {
-	response, err := http.Get(url)
-	if err != nil {
-		return false
-	}
-	defer response.Body.Close()
-	if _, err := ioutil.ReadAll(response.Body); err != nil {
-		return false
-	}
-	return true
-}
-
 // curlURL queries a given URL and returns its content
 func curlURL(url string) []byte {
 	response, err := http.Get(url)
@@ -251,110 +108,114 @@ func parseArray(anArray []interface{}, keyType string) {
 	}
 }
 
-// CephNanoS3Health loops for 30 seconds while testing Ceph RGW health
-func cephNanoS3Health(ContainerName string, RgwPort string) {
-	// setting timeout
-	timeout := 30
-	poll := 0
-	ips, _ := getInterfaceIPv4s()
-	// Taking the first IP is probably not ideal
-	// IMHO, using the interface with most of the traffic is better
-	url := "http://" + ips[0].String() + ":" + RgwPort
-
-	for poll < timeout {
-		if curlTestURL(url) {
-			return
+// getRuntime returns the container runtime selected with --runtime or CN_RUNTIME, Docker by default
+func getRuntime() nano.Runtime {
+	if runtimeCli == nil {
+		rt, err := nano.NewRuntime(RuntimeName)
+		if err != nil {
+			log.Fatal(err)
 		}
-		time.Sleep(time.Second * 1)
-		poll++
+		runtimeCli = rt
 	}
-	fmt.Println("S3 gateway for cluster " + ContainerName + " is not responding. Showing S3 logs:")
-	showS3Logs(ContainerName)
-	log.Fatal("Please open an issue at: https://github.com/ceph/cn.")
+	return runtimeCli
 }
 
-// echoInfo prints useful information about Ceph Nano
-func echoInfo(ContainerName string) {
-	// Get listening port
-	RgwPort := dockerInspect(ContainerName, "PortBindings")
-
-	// Always wait the container to be ready
-	cephNanoHealth(ContainerName)
-	cephNanoS3Health(ContainerName, RgwPort)
-
-	// Fetch Amazon Keys
-	CephNanoAccessKey, CephNanoSecretKey := getAwsKey(ContainerName)
-
-	// Get Ceph health
-	cmd := []string{"ceph", "health"}
-	c := execContainer(ContainerName, cmd)
-
-	// Get IPs, later using the first IP of the list is not ideal
-	// However, Docker binds RGW port on 0.0.0.0 so any address will work
-	ips, _ := getInterfaceIPv4s()
-
-	// Get the working directory
-	dir := dockerInspect(ContainerName, "Binds")
-
-	InfoLine :=
-		"\n" + strings.TrimSpace(string(c)) + " is the Ceph status \n" +
-			"S3 object server address is: http://" + ips[0].String() + ":" + RgwPort + "\n" +
-			"S3 user is: nano \n" +
-			"S3 access key is: " + CephNanoAccessKey + "\n" +
-			"S3 secret key is: " + CephNanoSecretKey + "\n" +
-			"Your working directory is: " + dir + "\n"
-	fmt.Println(InfoLine)
+// getCluster returns the cluster living in a given container
+func getCluster(ContainerName string) *nano.Cluster {
+	cluster, err := nano.Get(ctx, getRuntime(), ContainerName[len(ContainerNamePrefix):])
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cluster
 }
 
-// getAwsKey gets AWS keys from inside the container
-func getAwsKey(ContainerName string) (string, string) {
-	cmd := []string{"cat", "/nano_user_details"}
-
-	output := execContainer(ContainerName, cmd)
-
-	// declare structures for json
-	type s3Details []struct {
-		AccessKey string `json:"Access_key"`
-		SecretKey string `json:"Secret_key"`
+// containerState returns the state of a container, empty if it does not exist
+func containerState(ContainerName string) string {
+	info, err := getRuntime().InspectContainer(ctx, ContainerName)
+	if err == nano.ErrNotFound {
+		return ""
 	}
-	type jason struct {
-		Keys s3Details
+	if err != nil {
+		log.Fatal(err)
 	}
-	// assign variable to our json struct
-	var parsedMap jason
+	return info.State
+}
 
-	json.Unmarshal(output, &parsedMap)
+// waitCluster waits for a cluster to be healthy, showing the logs when it never gets there
+func waitCluster(cluster *nano.Cluster) {
+	defer cancelOnInterrupt()()
+	if err := cluster.Wait(ctx); err != nil {
+		checkHealthError(err)
+	}
+}
 
-	CephNanoAccessKey := parsedMap.Keys[0].AccessKey
-	CephNanoSecretKey := parsedMap.Keys[0].SecretKey
-	return CephNanoAccessKey, CephNanoSecretKey
+// checkHealthError exits, with the logs of the cluster when it never became healthy
+// waits that timed out or were interrupted exit with exitTimeout or exitInterrupted
+func checkHealthError(err error) {
+	if err == context.Canceled {
+		fmt.Fprintln(os.Stderr, "Interrupted.")
+		os.Exit(exitInterrupted)
+	}
+	healthErr, ok := err.(*nano.HealthError)
+	if !ok {
+		log.Fatal(err)
+	}
+	if healthErr.Service == "s3" {
+		fmt.Println("S3 gateway for cluster " + healthErr.Cluster + " is not responding. Showing S3 logs:")
+	} else {
+		fmt.Println("The container " + ContainerNamePrefix + healthErr.Cluster + " never reached a clean state. Showing the container logs now:")
+	}
+	fmt.Println(healthErr.Logs)
+	if healthErr.Timeout {
+		fmt.Fprintln(os.Stderr, "Timed out waiting for cluster "+healthErr.Cluster+".")
+		os.Exit(exitTimeout)
+	}
+	log.Fatal("Please open an issue at: https://github.com/ceph/cn with the logs above.")
 }
 
-// dockerInspect inspects the container Binds
-func dockerInspect(ContainerName string, pattern string) string {
-	inspect, err := getDocker().ContainerInspect(ctx, ContainerName)
+// echoInfo prints useful information about Ceph Nano
+func echoInfo(cluster *nano.Cluster) {
+	// Get Ceph health
+	health, err := cluster.CephHealth(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if pattern == "Binds" {
-		parts := strings.Split(inspect.HostConfig.Binds[0], ":")
-		return parts[0]
+	if structuredOutput() {
+		document := newClusterDocument(cluster)
+		document.Health = health
+		printDocument(document)
+		return
 	}
 
-	if pattern == "PortBindings" {
-		parts := strings.Split(inspect.Config.Env[0], "=")
-		return parts[1]
+	endpoint := cluster.Endpoint
+	if cluster.BindAddress == nano.DefaultBindAddress {
+		endpoint += " (published on all the interfaces)"
 	}
 
-	// this assumes a default that we are looking for the image name
-	parts := inspect.Config.Image
-	return parts
+	InfoLine :=
+		"\n" + health + " is the Ceph status \n" +
+			"S3 object server address is: " + endpoint + "\n" +
+			"S3 user is: " + cluster.User + " \n" +
+			"S3 access key is: " + cluster.AccessKey + "\n" +
+			"S3 secret key is: " + cluster.SecretKey + "\n" +
+			"Your working directory is: " + cluster.WorkDir + "\n" +
+			"Resources are: " + formatResources(cluster) + "\n"
+	fmt.Println(InfoLine)
+}
+
+// formatResources describes the resources given to a cluster
+func formatResources(cluster *nano.Cluster) string {
+	size := "image default"
+	if cluster.Size > 0 {
+		size = formatSize(cluster.Size)
+	}
+	return fmt.Sprintf("%s of memory, %g CPU(s), %s OSD", formatSize(cluster.Memory), cluster.CPUs, size)
 }
 
 // inspectImage inspects a given image
 func inspectImage(ImageID string, dataType string) string {
-	i, _, err := getDocker().ImageInspectWithRaw(ctx, ImageID)
+	i, err := getRuntime().InspectImage(ctx, ImageID)
 	if err != nil {
 		// sometimes the image does not exist anymore, we want to report that
 		return "image is not present, did you remove it?"
@@ -370,49 +231,37 @@ func inspectImage(ImageID string, dataType string) string {
 	if dataType == "created" {
 		return i.Created
 	}
-	if len(i.ContainerConfig.Labels["RELEASE"]) == 0 {
+	if len(i.Labels["RELEASE"]) == 0 {
 		return "unknown image release, are you running an official image?"
 	}
-	return i.ContainerConfig.Labels["RELEASE"]
+	return i.Labels["RELEASE"]
 }
 
 // pullImage downloads the container image
 func pullImage() bool {
-	_, _, err := getDocker().ImageInspectWithRaw(ctx, ImageName)
-	if err != nil {
+	_, err := getRuntime().InspectImage(ctx, ImageName)
+	if err == nano.ErrNotFound {
 		fmt.Print("The container image is not present, pulling it. \n" +
 			"This operation can take a few minutes.")
 
-		out, err := getDocker().ImagePull(ctx, ImageName, types.ImagePullOptions{})
-		if err != nil {
+		if err := getRuntime().PullImage(ctx, ImageName, os.Stdout); err != nil {
 			// the error message will appear on a new line after the info above
 			log.Println()
 			log.Fatal(err)
 		}
-
-		reader := bufio.NewReader(out)
-		defer out.Close() // pullResp is io.ReadCloser
-		var respo bytes.Buffer
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err != nil {
-				// it could be EOF or read error
-				break
-			}
-			respo.Write(line)
-			respo.WriteByte('\n')
-			fmt.Print(".")
-		}
 		fmt.Println("")
 		return true
 	}
+	if err != nil {
+		log.Fatal(err)
+	}
 	return false
 }
 
 func notExistCheck(ContainerName string) {
 	ContainerNameToShow := ContainerName[len(ContainerNamePrefix):]
 
-	if (!containerStatus(ContainerName, false, "running")) && (!containerStatus(ContainerName, false, "exited")) {
+	if state := containerState(ContainerName); state != "running" && state != "exited" {
 		fmt.Println("Cluster " + ContainerNameToShow + " does not exist yet.")
 		os.Exit(0)
 	}
@@ -421,128 +270,52 @@ func notExistCheck(ContainerName string) {
 func notRunningCheck(ContainerName string) {
 	ContainerNameToShow := ContainerName[len(ContainerNamePrefix):]
 
-	if status := containerStatus(ContainerName, true, "exited"); status {
+	if containerState(ContainerName) == "exited" {
 		fmt.Println("Cluster " + ContainerNameToShow + " is not running.")
 		os.Exit(0)
 	}
 }
 
-func copyFile(srcName, dstName string) (int64, error) {
-	src, e := os.Open(srcName)
-	if e != nil {
-		return 0, errors.New("Error while opening file for reading. Caused by: " + e.Error())
-	}
-
-	dst, e := os.Create(dstName)
-	if e != nil {
-		src.Close()
-		return 0, errors.New("Error while opening file for writing. Caused by: " + e.Error())
-	}
-
-	numBytesWritten, e := io.Copy(dst, src)
-	if e != nil {
-		dst.Close()
-		src.Close()
-		return 0, errors.New("Error while copying. Caused by: " + e.Error())
-	}
-
-	e = dst.Close()
-	if e != nil {
-		src.Close()
-		return numBytesWritten, errors.New("Error while closing. Caused by: " + e.Error())
-	}
-
-	e = src.Close()
-	if e != nil {
-		return numBytesWritten, errors.New("Error while closing. Caused by: " + e.Error())
-	}
-
-	return numBytesWritten, nil
+// sizeUnits maps the size suffixes we accept to their value in bytes
+// both 'M' and 'MiB' are understood as mebibytes, like Docker does
+var sizeUnits = map[string]int64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
 }
 
-// copyDir recursively copies a directory tree, attempting to preserve permissions.
-// Source directory must exist, destination directory must *not* exist.
-// Symlinks are ignored and skipped.
-func copyDir(src string, dst string) (err error) {
-	src = filepath.Clean(src)
-	dst = filepath.Clean(dst)
-
-	si, err := os.Stat(src)
-	if err != nil {
-		return errors.New("Error can not stat source. Caused by: " + err.Error())
+// parseSize converts a human readable size like '512M' or '1GiB' into bytes
+func parseSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	i := strings.IndexFunc(size, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i == -1 {
+		i = len(size)
 	}
-	if !si.IsDir() {
-		return errors.New("source is not a directory")
-	}
-
-	_, err = os.Stat(dst)
-	if err != nil && !os.IsNotExist(err) {
-		return errors.New("Error can not stat destination. Caused by: " + err.Error())
-	}
-	if err == nil {
-		return fmt.Errorf("destination already exists")
-	}
-
-	err = os.MkdirAll(dst, si.Mode())
-	if err != nil {
-		return errors.New("Error can not create directories. Caused by: " + err.Error())
-	}
-
-	entries, err := ioutil.ReadDir(src)
-	if err != nil {
-		return errors.New("Error can not read directories. Caused by: " + err.Error())
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(size[i:]))]
+	if !ok || i == 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a number followed by an optional unit (K, M, G, T)", size)
 	}
-
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-
-		if entry.IsDir() {
-			err = copyDir(srcPath, dstPath)
-			if err != nil {
-				return errors.New("Error copying directory. Caused by: " + err.Error())
-			}
-		} else {
-			// Skip symlinks.
-			if entry.Mode()&os.ModeSymlink != 0 {
-				continue
-			}
-
-			_, err = copyFile(srcPath, dstPath)
-			if err != nil {
-				return errors.New("Error copying file. Caused by: " + err.Error())
-			}
-		}
+	value, err := strconv.ParseFloat(size[:i], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a number followed by an optional unit (K, M, G, T)", size)
 	}
-
-	return nil
+	return int64(value * float64(unit)), nil
 }
 
-// checkPortInUsed checks if a port is in-used
-func checkPortInUsed(portNum string) bool {
-	hostName := "0.0.0.0"
-	seconds := 1
-	timeOut := time.Duration(seconds) * time.Second
-
-	_, err := net.DialTimeout("tcp", net.JoinHostPort(hostName, portNum), timeOut)
-
-	// if there is an error this means the port is not used
-	// and the connection can not be established
-	if err != nil {
-		return true
+// formatSize converts a number of bytes into a human readable size
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(size)
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
 	}
-	return false
-}
-
-// generateRGWPortToUse generates the binding port for Ceph Rados Gateway
-func generateRGWPortToUse() string {
-	maxPort := 8100
-	for i := 8000; i <= maxPort; i++ {
-		portNumStr := fmt.Sprint(i)
-		status := checkPortInUsed(portNumStr)
-		if status {
-			return portNumStr
-		}
+	if i == 0 {
+		return fmt.Sprintf("%d %s", size, units[i])
 	}
-	return "notfound"
+	return fmt.Sprintf("%.1f %s", value, units[i])
 }
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdVolume = &cobra.Command{
+		Use:   "volume [command] [arg]",
+		Short: "Interact with the volumes keeping the data of persistent clusters",
+		Args:  cobra.NoArgs,
+	}
+)
+
+func init() {
+	cmdVolume.AddCommand(
+		CliVolumeList(),
+		CliVolumeRemove(),
+	)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/apcera/termtables"
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+// CliVolumeList is the Cobra CLI call
+func CliVolumeList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "Print the volumes of the persistent clusters",
+		Long: "Print the volumes of the clusters started with --persist.\n" +
+			"Volumes of a cluster that does not exist anymore are orphans, starting the cluster again uses them.",
+		Args: cobra.NoArgs,
+		Run:  listVolumes,
+	}
+	return cmd
+}
+
+// listVolumes prints the volumes of the persistent clusters
+func listVolumes(cmd *cobra.Command, args []string) {
+	volumes, err := nano.ListVolumes(ctx, getRuntime())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if structuredOutput() {
+		documents := []volumeDocument{}
+		for _, volume := range volumes {
+			documents = append(documents, volumeDocument{
+				Name:       volume.Name,
+				Cluster:    volume.Cluster,
+				Orphan:     volume.Orphan,
+				Mountpoint: volume.Mountpoint,
+			})
+		}
+		printDocument(documents)
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.AddHeaders("NAME", "CLUSTER", "STATUS", "MOUNTPOINT")
+	for _, volume := range volumes {
+		status := "in use"
+		if volume.Orphan {
+			status = "orphan"
+		}
+		table.AddRow(volume.Name, volume.Cluster, status, volume.Mountpoint)
+	}
+	fmt.Println(table.Render())
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// RemoveOrphans removes every volume whose cluster does not exist anymore
+	RemoveOrphans bool
+)
+
+// CliVolumeRemove is the Cobra CLI call
+func CliVolumeRemove() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm [VOLUME...]",
+		Short: "Remove volumes of persistent clusters. DANGEROUS!",
+		Long: "Remove volumes of persistent clusters, the data they hold is lost.\n" +
+			"The volumes of an existing cluster are removed along with it by 'cn cluster purge'.",
+		Run: removeVolumes,
+		Example: "cn volume rm ceph-nano-mycluster-var-lib-ceph ceph-nano-mycluster-etc-ceph \n" +
+			"cn volume rm --orphans",
+	}
+	cmd.Flags().BoolVar(&RemoveOrphans, "orphans", false, "Remove every volume whose cluster does not exist anymore")
+
+	return cmd
+}
+
+// removeVolumes removes volumes of persistent clusters
+func removeVolumes(cmd *cobra.Command, args []string) {
+	names := args
+	if RemoveOrphans {
+		volumes, err := nano.ListVolumes(ctx, getRuntime())
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, volume := range volumes {
+			if volume.Orphan {
+				names = append(names, volume.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		fmt.Printf("Please give the volumes to remove, or use --orphans. \n \n")
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	for _, name := range names {
+		if err := nano.RemoveVolume(ctx, getRuntime(), name); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Volume " + name + " removed")
+	}
+}
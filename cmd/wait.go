@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ceph/cn/nano"
+	"github.com/spf13/cobra"
+)
+
+// WaitCondition is what 'cn cluster wait' waits for
+var WaitCondition string
+
+// CliClusterWait is the Cobra CLI call
+func CliClusterWait() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wait NAME",
+		Short: "Wait for a cluster to be ready",
+		Long: "Wait until a condition holds on a cluster, e.g. after 'cn cluster start --no-wait'. \n" +
+			"healthy waits for 'ceph health' to be HEALTH_OK, or to only warn about the single OSD, s3 for the S3 gateway to answer signed requests \n" +
+			"and bucket=NAME for a bucket to exist. cn exits with code 124 when the condition does not hold in time.",
+		Args: cobra.ExactArgs(1),
+		Run:  waitNano,
+		Example: "cn cluster wait mycluster \n" +
+			"cn cluster wait mycluster --for s3 --timeout 2m \n" +
+			"cn cluster wait mycluster --for bucket=fixtures",
+	}
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&WaitCondition, "for", nano.ConditionHealthy, "Condition to wait for: healthy, s3 or bucket=NAME")
+	cmd.Flags().DurationVar(&WaitTimeout, "timeout", nano.DefaultTimeout, "How long to wait for the condition")
+
+	return cmd
+}
+
+// waitNano waits for a condition on a cluster
+func waitNano(cmd *cobra.Command, args []string) {
+	defer cancelOnInterrupt()()
+	ContainerName := ContainerNamePrefix + args[0]
+
+	notExistCheck(ContainerName)
+	condition, err := nano.ParseCondition(WaitCondition)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cluster := getCluster(ContainerName)
+	waitCtx, cancel := context.WithTimeout(ctx, WaitTimeout)
+	defer cancel()
+	if err := cluster.WaitFor(waitCtx, condition); err != nil {
+		if _, ok := err.(*nano.ConditionError); ok {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitTimeout)
+		}
+		checkHealthError(err)
+	}
+	fmt.Fprintln(infoOutput(), "Cluster "+args[0]+" is ready: "+condition.String())
+}
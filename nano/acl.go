@@ -0,0 +1,135 @@
+package nano
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// groups of users a grant can be given to, besides a single user
+const (
+	GroupAllUsers           = "AllUsers"           // anyone, anonymous requests included
+	GroupAuthenticatedUsers = "AuthenticatedUsers" // any user of the cluster
+)
+
+// groupURIPrefix prefixes the URIs of the groups in ACLs
+const groupURIPrefix = "http://acs.amazonaws.com/groups/global/"
+
+// permissions a grant gives
+var aclPermissions = map[string]bool{
+	"READ":         true,
+	"WRITE":        true,
+	"READ_ACP":     true,
+	"WRITE_ACP":    true,
+	"FULL_CONTROL": true,
+}
+
+// Grant gives a permission on a bucket or object
+type Grant struct {
+	Grantee    string `json:"grantee"`    // UID of a user, GroupAllUsers or GroupAuthenticatedUsers
+	Permission string `json:"permission"` // READ, WRITE, READ_ACP, WRITE_ACP or FULL_CONTROL
+}
+
+// IsGroup tells if the grant is given to a group of users rather than to a single user
+func (g Grant) IsGroup() bool {
+	return g.Grantee == GroupAllUsers || g.Grantee == GroupAuthenticatedUsers
+}
+
+// AccessControlList is the owner of a bucket or object and the grants given on it
+type AccessControlList struct {
+	Owner  string
+	Grants []Grant
+}
+
+// cannedACL returns the canned ACL matching grants, grants given to single users have no canned equivalent
+func cannedACL(grants []Grant) string {
+	acl := "private"
+	for _, grant := range grants {
+		switch {
+		case grant.Grantee == GroupAllUsers && grant.Permission == "WRITE":
+			acl = "public-read-write"
+		case grant.Grantee == GroupAllUsers && grant.Permission == "READ" && acl != "public-read-write":
+			acl = "public-read"
+		case grant.Grantee == GroupAuthenticatedUsers && grant.Permission == "READ" && acl == "private":
+			acl = "authenticated-read"
+		}
+	}
+	return acl
+}
+
+// readACL is an ACL as the gateway returns it
+type readACL struct {
+	Owner  string `xml:"Owner>ID"`
+	Grants []struct {
+		ID         string `xml:"Grantee>ID"`
+		URI        string `xml:"Grantee>URI"`
+		Permission string `xml:"Permission"`
+	} `xml:"AccessControlList>Grant"`
+}
+
+// writeACL is the body replacing an ACL
+// the gateway reads the type of the grantees from their literal xsi:type attribute
+type writeACL struct {
+	XMLName xml.Name     `xml:"http://s3.amazonaws.com/doc/2006-03-01/ AccessControlPolicy"`
+	Owner   string       `xml:"Owner>ID"`
+	Grants  []writeGrant `xml:"AccessControlList>Grant"`
+}
+
+type writeGrant struct {
+	Grantee    writeGrantee `xml:"Grantee"`
+	Permission string       `xml:"Permission"`
+}
+
+type writeGrantee struct {
+	XMLNS string `xml:"xmlns:xsi,attr"`
+	Type  string `xml:"xsi:type,attr"`
+	ID    string `xml:"ID,omitempty"`
+	URI   string `xml:"URI,omitempty"`
+}
+
+// GetACLGrants returns the owner and grants of a bucket, or of an object of it when object is not empty
+func (e *S3Endpoint) GetACLGrants(ctx context.Context, bucket, object string) (*AccessControlList, error) {
+	content, err := e.request(ctx, http.MethodGet, bucket, object, url.Values{"acl": {""}}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var policy readACL
+	if err := xml.Unmarshal(content, &policy); err != nil {
+		return nil, err
+	}
+
+	acl := &AccessControlList{Owner: policy.Owner}
+	for _, grant := range policy.Grants {
+		grantee := grant.ID
+		if grant.URI != "" {
+			grantee = strings.TrimPrefix(grant.URI, groupURIPrefix)
+		}
+		acl.Grants = append(acl.Grants, Grant{Grantee: grantee, Permission: grant.Permission})
+	}
+	return acl, nil
+}
+
+// SetACLGrants replaces the grants of a bucket, or of an object of it when object is not empty
+// the owner keeps its ownership but only has the permissions granted to it
+func (e *S3Endpoint) SetACLGrants(ctx context.Context, bucket, object string, acl *AccessControlList) error {
+	body := writeACL{Owner: acl.Owner}
+	for _, grant := range acl.Grants {
+		if !aclPermissions[grant.Permission] {
+			return fmt.Errorf("unknown permission %q, use READ, WRITE, READ_ACP, WRITE_ACP or FULL_CONTROL", grant.Permission)
+		}
+		grantee := writeGrantee{XMLNS: "http://www.w3.org/2001/XMLSchema-instance", Type: "CanonicalUser", ID: grant.Grantee}
+		if grant.IsGroup() {
+			grantee = writeGrantee{XMLNS: grantee.XMLNS, Type: "Group", URI: groupURIPrefix + grant.Grantee}
+		}
+		body.Grants = append(body.Grants, writeGrant{Grantee: grantee, Permission: grant.Permission})
+	}
+	content, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+	_, err = e.request(ctx, http.MethodPut, bucket, object, url.Values{"acl": {""}}, nil, content)
+	return err
+}
@@ -0,0 +1,326 @@
+package nano
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+// archiveIndexName is the first entry of an archive, it describes everything the archive holds
+const archiveIndexName = "index.json"
+
+// ArchiveVersion is the version of the archive format written by Export
+// version 2 adds the grants of the ACLs, which older releases of cn would drop
+const ArchiveVersion = 2
+
+// ArchiveIndex describes the buckets and objects of an archive
+type ArchiveIndex struct {
+	Version int             `json:"version"`
+	Created time.Time       `json:"created"`
+	Source  string          `json:"source"` // URL of the endpoint the archive was exported from
+	Buckets []ArchiveBucket `json:"buckets"`
+}
+
+// ArchiveBucket is a bucket of an archive along with its configuration
+type ArchiveBucket struct {
+	Name       string          `json:"name"`
+	Versioning string          `json:"versioning,omitempty"` // "Enabled" or "Suspended"
+	ACL        string          `json:"acl,omitempty"`        // canned ACL
+	Grants     []Grant         `json:"grants,omitempty"`     // grants given to others than the owner
+	Policy     string          `json:"policy,omitempty"`     // JSON bucket policy
+	Lifecycle  string          `json:"lifecycle,omitempty"`  // XML lifecycle configuration
+	Objects    []ArchiveObject `json:"objects"`
+}
+
+// ArchiveObject is an object of an archive, its data is the archive entry called File
+type ArchiveObject struct {
+	Key         string            `json:"key"`
+	File        string            `json:"file"`
+	Size        int64             `json:"size"`
+	ETag        string            `json:"etag"`
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	ACL         string            `json:"acl,omitempty"`    // canned ACL
+	Grants      []Grant           `json:"grants,omitempty"` // grants given to others than the owner
+}
+
+// Export writes every bucket of the endpoint to a tar archive: a JSON index followed by the data of the objects
+// only the latest version of the objects is exported
+func (e *S3Endpoint) Export(ctx context.Context, w io.Writer) (*ArchiveIndex, error) {
+	s3Client, err := e.Client()
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.exportIndex(ctx, s3Client)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := tar.NewWriter(w)
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeArchiveEntry(archive, archiveIndexName, int64(len(content)), bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	for _, bucket := range index.Buckets {
+		for _, object := range bucket.Objects {
+			reader, err := s3Client.GetObjectWithContext(ctx, bucket.Name, object.Key, minio.GetObjectOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("unable to export %s/%s: %s", bucket.Name, object.Key, err)
+			}
+			err = writeArchiveEntry(archive, object.File, object.Size, reader)
+			reader.Close()
+			if err != nil {
+				return nil, fmt.Errorf("unable to export %s/%s: %s", bucket.Name, object.Key, err)
+			}
+		}
+	}
+	return index, archive.Close()
+}
+
+// exportIndex lists the buckets and objects of the endpoint along with their configuration
+func (e *S3Endpoint) exportIndex(ctx context.Context, s3Client *minio.Client) (*ArchiveIndex, error) {
+	buckets, err := s3Client.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	index := &ArchiveIndex{Version: ArchiveVersion, Created: time.Now().UTC(), Source: e.URL}
+	for i, bucketInfo := range buckets {
+		bucket := ArchiveBucket{Name: bucketInfo.Name}
+		if bucket.Versioning, err = e.GetBucketVersioning(ctx, bucket.Name); err != nil {
+			return nil, fmt.Errorf("unable to read the versioning of bucket %s: %s", bucket.Name, err)
+		}
+		if bucket.ACL, bucket.Grants, err = e.exportACL(ctx, bucket.Name, ""); err != nil {
+			return nil, fmt.Errorf("unable to read the ACL of bucket %s: %s", bucket.Name, err)
+		}
+		if bucket.Policy, err = s3Client.GetBucketPolicy(bucket.Name); err != nil {
+			return nil, fmt.Errorf("unable to read the policy of bucket %s: %s", bucket.Name, err)
+		}
+		if bucket.Lifecycle, err = s3Client.GetBucketLifecycle(bucket.Name); err != nil {
+			return nil, fmt.Errorf("unable to read the lifecycle of bucket %s: %s", bucket.Name, err)
+		}
+
+		doneCh := make(chan struct{})
+		for objectInfo := range s3Client.ListObjectsV2(bucket.Name, "", true, doneCh) {
+			if objectInfo.Err != nil {
+				close(doneCh)
+				return nil, objectInfo.Err
+			}
+			object, err := e.exportObject(ctx, s3Client, bucket.Name, objectInfo.Key)
+			if err != nil {
+				close(doneCh)
+				return nil, fmt.Errorf("unable to read object %s/%s: %s", bucket.Name, objectInfo.Key, err)
+			}
+			// Keys can hold anything, entries are named after their position instead
+			object.File = fmt.Sprintf("data/%d/%d", i, len(bucket.Objects))
+			bucket.Objects = append(bucket.Objects, object)
+		}
+		close(doneCh)
+		index.Buckets = append(index.Buckets, bucket)
+	}
+	return index, nil
+}
+
+// exportObject returns the description of an object
+func (e *S3Endpoint) exportObject(ctx context.Context, s3Client *minio.Client, bucket, key string) (ArchiveObject, error) {
+	info, err := s3Client.StatObject(bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ArchiveObject{}, err
+	}
+	object := ArchiveObject{
+		Key:         key,
+		Size:        info.Size,
+		ETag:        strings.Trim(info.ETag, "\""),
+		ContentType: info.ContentType,
+	}
+	for header, values := range info.Metadata {
+		if strings.HasPrefix(header, "X-Amz-Meta-") && len(values) > 0 {
+			if object.Metadata == nil {
+				object.Metadata = map[string]string{}
+			}
+			object.Metadata[strings.ToLower(strings.TrimPrefix(header, "X-Amz-Meta-"))] = values[0]
+		}
+	}
+	if object.Tags, err = e.GetObjectTags(ctx, bucket, key); err != nil {
+		return ArchiveObject{}, err
+	}
+	if object.ACL, object.Grants, err = e.exportACL(ctx, bucket, key); err != nil {
+		return ArchiveObject{}, err
+	}
+	return object, nil
+}
+
+// exportACL returns the canned ACL of a bucket or object along with the grants given to others than its owner
+// the grants of the owner are left out, on import they go to whoever owns the bucket or object then
+func (e *S3Endpoint) exportACL(ctx context.Context, bucket, object string) (string, []Grant, error) {
+	acl, err := e.GetACLGrants(ctx, bucket, object)
+	if err != nil {
+		return "", nil, err
+	}
+	var grants []Grant
+	for _, grant := range acl.Grants {
+		if grant.Grantee != acl.Owner {
+			grants = append(grants, grant)
+		}
+	}
+	return cannedACL(acl.Grants), grants, nil
+}
+
+// writeArchiveEntry adds a file to an archive, failing if the reader does not hold exactly size bytes
+func writeArchiveEntry(archive *tar.Writer, name string, size int64, reader io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: time.Now(),
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.CopyN(archive, reader, size)
+	return err
+}
+
+// Import recreates the buckets and objects of an archive written by Export
+// existing buckets are kept, existing objects are overwritten
+func (e *S3Endpoint) Import(ctx context.Context, r io.Reader) (*ArchiveIndex, error) {
+	s3Client, err := e.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	archive := tar.NewReader(r)
+	header, err := archive.Next()
+	if err != nil || header.Name != archiveIndexName {
+		return nil, fmt.Errorf("not a cn archive, the first entry must be %s", archiveIndexName)
+	}
+	var index ArchiveIndex
+	if err := json.NewDecoder(archive).Decode(&index); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %s", archiveIndexName, err)
+	}
+	if index.Version > ArchiveVersion {
+		return nil, fmt.Errorf("the archive was written by a newer cn, format %d is not supported", index.Version)
+	}
+
+	type location struct {
+		bucket string
+		object ArchiveObject
+	}
+	files := map[string]location{}
+	for _, bucket := range index.Buckets {
+		if err := e.importBucket(ctx, s3Client, bucket); err != nil {
+			return nil, err
+		}
+		for _, object := range bucket.Objects {
+			files[object.File] = location{bucket.Name, object}
+		}
+	}
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		file, ok := files[header.Name]
+		if !ok {
+			continue
+		}
+		if err := e.importObject(ctx, s3Client, file.bucket, file.object, archive, header.Size); err != nil {
+			return nil, fmt.Errorf("unable to import %s/%s: %s", file.bucket, file.object.Key, err)
+		}
+		delete(files, header.Name)
+	}
+	if len(files) > 0 {
+		return nil, fmt.Errorf("the archive is truncated, %d object(s) are missing", len(files))
+	}
+	return &index, nil
+}
+
+// importBucket creates a bucket of an archive and applies its configuration
+func (e *S3Endpoint) importBucket(ctx context.Context, s3Client *minio.Client, bucket ArchiveBucket) error {
+	err := s3Client.MakeBucket(bucket.Name, "")
+	if code := minio.ToErrorResponse(err).Code; code == "BucketAlreadyOwnedByYou" || code == "BucketAlreadyExists" {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create bucket %s: %s", bucket.Name, err)
+	}
+	if bucket.Versioning != "" {
+		if err := e.SetBucketVersioning(ctx, bucket.Name, bucket.Versioning == "Enabled"); err != nil {
+			return fmt.Errorf("unable to set the versioning of bucket %s: %s", bucket.Name, err)
+		}
+	}
+	if err := e.importACL(ctx, bucket.Name, "", bucket.ACL, bucket.Grants); err != nil {
+		return fmt.Errorf("unable to set the ACL of bucket %s: %s", bucket.Name, err)
+	}
+	if bucket.Policy != "" {
+		if err := s3Client.SetBucketPolicy(bucket.Name, bucket.Policy); err != nil {
+			return fmt.Errorf("unable to set the policy of bucket %s: %s", bucket.Name, err)
+		}
+	}
+	if bucket.Lifecycle != "" {
+		if err := s3Client.SetBucketLifecycle(bucket.Name, bucket.Lifecycle); err != nil {
+			return fmt.Errorf("unable to set the lifecycle of bucket %s: %s", bucket.Name, err)
+		}
+	}
+	return nil
+}
+
+// importObject uploads an object of an archive and applies its tags and ACL
+func (e *S3Endpoint) importObject(ctx context.Context, s3Client *minio.Client, bucket string, object ArchiveObject, data io.Reader, size int64) error {
+	_, err := s3Client.PutObjectWithContext(ctx, bucket, object.Key, data, size, minio.PutObjectOptions{
+		ContentType:  object.ContentType,
+		UserMetadata: object.Metadata,
+	})
+	if err != nil {
+		return err
+	}
+	if len(object.Tags) > 0 {
+		if err := e.SetObjectTags(ctx, bucket, object.Key, object.Tags); err != nil {
+			return err
+		}
+	}
+	return e.importACL(ctx, bucket, object.Key, object.ACL, object.Grants)
+}
+
+// importACL applies the canned ACL or the grants of a bucket or object of an archive
+// the grants are added to the ones of the owner, the users they are given to must exist
+func (e *S3Endpoint) importACL(ctx context.Context, bucket, object string, canned string, grants []Grant) error {
+	if len(grants) == 0 {
+		// Private is the default, not setting it spares endpoints where ACLs are disabled
+		if canned == "" || canned == "private" {
+			return nil
+		}
+		return e.SetACL(ctx, bucket, object, canned)
+	}
+
+	acl, err := e.GetACLGrants(ctx, bucket, object)
+	if err != nil {
+		return err
+	}
+	var owned []Grant
+	for _, grant := range acl.Grants {
+		if grant.Grantee == acl.Owner {
+			owned = append(owned, grant)
+		}
+	}
+	acl.Grants = append(owned, grants...)
+	if err := e.SetACLGrants(ctx, bucket, object, acl); err != nil {
+		return fmt.Errorf("%s, do the users it grants access to exist?", err)
+	}
+	return nil
+}
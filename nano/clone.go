@@ -0,0 +1,109 @@
+package nano
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+// Clone creates a new cluster holding a copy of the state of this one, opts.Name is the name of the new cluster
+// the clone gets the image, resources and work directory of the source; only the network settings of opts are used,
+// the S3 gateway is published on a free port unless opts.Port pins one
+// a running source is stopped while its volumes are copied, then started again
+func (c *Cluster) Clone(ctx context.Context, opts Options) (*Cluster, error) {
+	if opts.Name == "" {
+		return nil, errors.New("a name is required for the clone")
+	}
+	if opts.Progress == nil {
+		opts.Progress = ioutil.Discard
+	}
+	if opts.BindAddress == "" {
+		opts.BindAddress = c.BindAddress
+	}
+	if opts.MinPort == 0 && opts.MaxPort == 0 {
+		opts.MinPort, opts.MaxPort = DefaultMinPort, DefaultMaxPort
+	}
+	if err := validateNetwork(opts); err != nil {
+		return nil, err
+	}
+
+	containerName := ContainerNamePrefix + opts.Name
+	if _, err := c.runtime.InspectContainer(ctx, containerName); err != ErrNotFound {
+		if err == nil {
+			err = fmt.Errorf("cluster %s already exists", opts.Name)
+		}
+		return nil, err
+	}
+	source, err := c.runtime.InspectContainer(ctx, c.ContainerName)
+	if err != nil {
+		return nil, err
+	}
+	rgwPort, err := generateRGWPortToUse(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Ceph daemons are named after the hostname and the gateway port is written in ceph.conf,
+	// the clone keeps both as they are and only publishes the gateway on its own host port
+	metadata := c.Metadata
+	metadata.Port = rgwPort
+	metadata.BindAddress = opts.BindAddress
+	metadata.Version = Version
+	metadata.Created = time.Now()
+	metadata.ClonedFrom = c.Name
+	if metadata.Persist {
+		if _, err := c.runtime.InspectVolume(ctx, volumeName(containerName, dataPaths[0])); err != ErrNotFound {
+			if err == nil {
+				err = fmt.Errorf("cluster %s left volumes behind, remove them first", opts.Name)
+			}
+			return nil, err
+		}
+		if err := prepareVolumes(ctx, c.runtime, containerName, &metadata); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.runtime.CreateContainer(ctx, metadata.containerSpec(containerName, source.Privileged)); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(opts.Progress, "Copying the data of cluster "+c.Name+"...")
+	err = c.stopped(ctx, func() error {
+		for _, containerPath := range statePaths() {
+			if err := c.copyTo(ctx, containerName, containerPath); err != nil {
+				return fmt.Errorf("unable to copy %s: %s", containerPath, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.runtime.RemoveContainer(ctx, containerName, true)
+		if metadata.Persist {
+			removeVolumes(ctx, c.runtime, containerName)
+		}
+		return nil, err
+	}
+
+	fmt.Fprintln(opts.Progress, "Starting cluster "+opts.Name+"...")
+	if err := c.runtime.StartContainer(ctx, containerName); err != nil {
+		return nil, err
+	}
+	clone, err := Get(ctx, c.runtime, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	return clone, clone.Wait(ctx)
+}
+
+// copyTo copies a path of the cluster container to the same path of another container
+func (c *Cluster) copyTo(ctx context.Context, containerName string, containerPath string) error {
+	archive, err := c.runtime.CopyFromContainer(ctx, c.ContainerName, containerPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	// Archives are rooted at the last element of the path they were taken from
+	return c.runtime.CopyToContainer(ctx, containerName, path.Dir(containerPath), archive)
+}
@@ -0,0 +1,179 @@
+package nano
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long Wait waits for a cluster when ctx has no deadline
+const DefaultTimeout = 90 * time.Second
+
+// startupPhases are the daemons the demo container starts, in order, and the binary that runs each of them
+// the entrypoint traces its commands (DEBUG=verbose), so a daemon is up once its binary shows in the logs
+var startupPhases = []struct {
+	name   string
+	binary string
+}{
+	{"mon", "ceph-mon"},
+	{"mgr", "ceph-mgr"},
+	{"osd", "ceph-osd"},
+	{"rgw", "radosgw"},
+}
+
+// HealthError is returned when a cluster does not become healthy
+// it carries the logs that help understand what went wrong
+type HealthError struct {
+	Cluster string
+	Service string // "ceph" or "s3"
+	Logs    string
+	Timeout bool // the deadline was reached, otherwise the container stopped before being healthy
+}
+
+func (e *HealthError) Error() string {
+	if e.Service == "s3" {
+		return "S3 gateway for cluster " + e.Cluster + " is not responding"
+	}
+	if e.Timeout {
+		return "the container " + ContainerNamePrefix + e.Cluster + " did not reach a clean state in time"
+	}
+	return "the container " + ContainerNamePrefix + e.Cluster + " never reached a clean state"
+}
+
+// Wait waits for the cluster to be healthy and its S3 gateway to answer, then loads its S3 keys
+// it gives up after DefaultTimeout unless ctx has a deadline, see WaitProgress
+func (c *Cluster) Wait(ctx context.Context) error {
+	return c.WaitProgress(ctx, nil)
+}
+
+// WaitProgress is Wait reporting the startup phases of the cluster (mon, mgr, osd and rgw up) to progress
+// a HealthError with Timeout set is returned when the deadline is reached, ctx.Err() when ctx is cancelled
+func (c *Cluster) WaitProgress(ctx context.Context, progress io.Writer) error {
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	if err := c.waitCeph(ctx, progress); err != nil {
+		return err
+	}
+	if err := c.waitS3(ctx); err != nil {
+		return err
+	}
+	return c.loadKeys(ctx)
+}
+
+// waitCeph follows the container logs until the entrypoint prints SUCCESS
+// the logs are read as they come from the last start of the container, so the lines of a previous boot
+// are ignored after a restart, each startup phase is reported once
+func (c *Cluster) waitCeph(ctx context.Context, progress io.Writer) error {
+	info, err := c.runtime.InspectContainer(ctx, c.ContainerName)
+	if err != nil {
+		return err
+	}
+	logsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	out, err := c.runtime.ContainerLogs(logsCtx, c.ContainerName, true, info.StartedAt)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	// Not every runtime ends the stream along with the request, closing it unblocks the reader
+	go func() {
+		<-logsCtx.Done()
+		out.Close()
+	}()
+
+	logs := new(bytes.Buffer)
+	phase := 0
+	// A bufio.Reader rather than a Scanner, lines have no length limit
+	reader := bufio.NewReader(out)
+	for {
+		line, readErr := reader.ReadString('\n')
+		logs.WriteString(line)
+		if phase < len(startupPhases) && runsBinary(line, startupPhases[phase].binary) {
+			fmt.Fprintln(progress, startupPhases[phase].name+" up")
+			phase++
+		}
+		if strings.Contains(line, "SUCCESS") {
+			return nil
+		}
+		if readErr != nil {
+			err = readErr
+			break
+		}
+	}
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return &HealthError{Cluster: c.Name, Service: "ceph", Logs: logs.String(), Timeout: true}
+	case context.Canceled:
+		return ctx.Err()
+	}
+	if err != io.EOF {
+		return fmt.Errorf("could not read the logs of the container %s: %s", c.ContainerName, err)
+	}
+	// if we reach here, the container stopped, something is broken in it
+	return &HealthError{Cluster: c.Name, Service: "ceph", Logs: logs.String()}
+}
+
+// runsBinary tells if a line of the entrypoint trace runs a binary, e.g: "+ ceph-mon --cluster ceph ..."
+func runsBinary(line string, binary string) bool {
+	fields := strings.Fields(strings.TrimLeft(line, "+ "))
+	return len(fields) > 0 && fields[0] == binary
+}
+
+// waitS3 tests Ceph RGW health every second until ctx is done
+func (c *Cluster) waitS3(ctx context.Context) error {
+	for !curlTestURL(ctx, c.Endpoint) {
+		err := sleep(ctx, time.Second)
+		if err == context.DeadlineExceeded {
+			// ctx is over, the logs are read without it
+			logs, _ := c.S3Logs(context.Background())
+			return &HealthError{Cluster: c.Name, Service: "s3", Logs: string(logs), Timeout: true}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// curlTestURL tests a given URL
+func curlTestURL(ctx context.Context, url string) bool {
+	if url == "" {
+		return false
+	}
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return false
+	}
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return false
+	}
+	defer response.Body.Close()
+	if _, err := ioutil.ReadAll(response.Body); err != nil {
+		return false
+	}
+	return true
+}
+
+// sleep waits for d, or less if ctx is done before
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
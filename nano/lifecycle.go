@@ -0,0 +1,149 @@
+package nano
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+// LifecycleConfiguration is the lifecycle of a bucket
+// it reads both the XML of the S3 API and the JSON of 'aws s3api put-bucket-lifecycle-configuration'
+type LifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration" json:"-" yaml:"-"`
+	Rules   []LifecycleRule `xml:"Rule" json:"Rules" yaml:"rules"`
+}
+
+// LifecycleRule is a rule of a lifecycle, the objects it applies to are selected with Filter or Prefix
+type LifecycleRule struct {
+	ID                             string                          `xml:"ID,omitempty" json:"ID,omitempty" yaml:"id,omitempty"`
+	Status                         string                          `xml:"Status" json:"Status" yaml:"status"` // "Enabled" or "Disabled"
+	Filter                         *LifecycleFilter                `xml:"Filter,omitempty" json:"Filter,omitempty" yaml:"filter,omitempty"`
+	Prefix                         *string                         `xml:"Prefix" json:"Prefix,omitempty" yaml:"prefix,omitempty"`
+	Expiration                     *LifecycleExpiration            `xml:"Expiration,omitempty" json:"Expiration,omitempty" yaml:"expiration,omitempty"`
+	NoncurrentVersionExpiration    *NoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty" json:"NoncurrentVersionExpiration,omitempty" yaml:"noncurrent_version_expiration,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty" json:"AbortIncompleteMultipartUpload,omitempty" yaml:"abort_incomplete_multipart_upload,omitempty"`
+}
+
+// LifecycleFilter selects the objects of a rule by prefix
+type LifecycleFilter struct {
+	Prefix string `xml:"Prefix" json:"Prefix" yaml:"prefix"`
+}
+
+// LifecycleExpiration expires the current version of the objects, after a number of days or at a date
+type LifecycleExpiration struct {
+	Days                      int    `xml:"Days,omitempty" json:"Days,omitempty" yaml:"days,omitempty"`
+	Date                      string `xml:"Date,omitempty" json:"Date,omitempty" yaml:"date,omitempty"`
+	ExpiredObjectDeleteMarker bool   `xml:"ExpiredObjectDeleteMarker,omitempty" json:"ExpiredObjectDeleteMarker,omitempty" yaml:"expired_object_delete_marker,omitempty"`
+}
+
+// NoncurrentVersionExpiration removes the versions of the objects a number of days after they stopped being the latest
+type NoncurrentVersionExpiration struct {
+	NoncurrentDays int `xml:"NoncurrentDays" json:"NoncurrentDays" yaml:"noncurrent_days"`
+}
+
+// AbortIncompleteMultipartUpload aborts the multipart uploads still not completed a number of days after they started
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation" json:"DaysAfterInitiation" yaml:"days_after_initiation"`
+}
+
+// ParseLifecycle reads a lifecycle given as XML or as JSON
+func ParseLifecycle(data []byte) (*LifecycleConfiguration, error) {
+	var lifecycle LifecycleConfiguration
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&lifecycle)
+	} else {
+		err = xml.Unmarshal(data, &lifecycle)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid lifecycle: %s", err)
+	}
+	if len(lifecycle.Rules) == 0 {
+		return nil, fmt.Errorf("invalid lifecycle: it has no rule")
+	}
+	for i, rule := range lifecycle.Rules {
+		if rule.Status != "Enabled" && rule.Status != "Disabled" {
+			return nil, fmt.Errorf("invalid lifecycle: the status of rule %d is %q, it is Enabled or Disabled", i+1, rule.Status)
+		}
+		// Releases of the Rados Gateway without filters want a prefix, the empty one selects every object
+		if rule.Filter == nil && rule.Prefix == nil {
+			lifecycle.Rules[i].Prefix = new(string)
+		}
+	}
+	return &lifecycle, nil
+}
+
+// SetBucketLifecycle replaces the lifecycle of a bucket
+func (e *S3Endpoint) SetBucketLifecycle(ctx context.Context, bucket string, lifecycle *LifecycleConfiguration) error {
+	body, err := xml.Marshal(lifecycle)
+	if err != nil {
+		return err
+	}
+	_, err = e.request(ctx, http.MethodPut, bucket, "", url.Values{"lifecycle": {""}}, nil, body)
+	return err
+}
+
+// GetBucketLifecycle returns the lifecycle of a bucket, nil when it has none
+func (e *S3Endpoint) GetBucketLifecycle(ctx context.Context, bucket string) (*LifecycleConfiguration, error) {
+	body, err := e.request(ctx, http.MethodGet, bucket, "", url.Values{"lifecycle": {""}}, nil, nil)
+	if minio.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lifecycle LifecycleConfiguration
+	if err := xml.Unmarshal(body, &lifecycle); err != nil {
+		return nil, err
+	}
+	return &lifecycle, nil
+}
+
+// RemoveBucketLifecycle removes the lifecycle of a bucket
+func (e *S3Endpoint) RemoveBucketLifecycle(ctx context.Context, bucket string) error {
+	_, err := e.request(ctx, http.MethodDelete, bucket, "", url.Values{"lifecycle": {""}}, nil, nil)
+	return err
+}
+
+// lifecycleDebugOption is the option of ceph.conf making the days of the lifecycle rules last a number of seconds
+const lifecycleDebugOption = "rgw_lc_debug_interval"
+
+// SetLifecycleDebugInterval makes a day of the lifecycle rules of the cluster last interval, for testing, 0 brings back real days
+// the S3 gateway reads it when it starts: the cluster is restarted and waited for, unless it already uses this interval
+func (c *Cluster) SetLifecycleDebugInterval(ctx context.Context, interval time.Duration) error {
+	seconds := int(interval / time.Second)
+	if interval > 0 && seconds == 0 {
+		return fmt.Errorf("the lifecycle debug interval is counted in seconds, %s is too short", interval)
+	}
+	output, err := c.Exec(ctx, []string{"sh", "-c", "grep '^" + lifecycleDebugOption + " ' /etc/ceph/ceph.conf || true"})
+	if err != nil {
+		return err
+	}
+	current := 0
+	if fields := strings.Fields(string(output)); len(fields) == 3 {
+		current, _ = strconv.Atoi(fields[2])
+	}
+	if current == seconds {
+		return nil
+	}
+
+	script := "sed -i '/^" + lifecycleDebugOption + " /d' /etc/ceph/ceph.conf"
+	if seconds > 0 {
+		script += fmt.Sprintf(" && sed -i '/^\\[global\\]/a %s = %d' /etc/ceph/ceph.conf", lifecycleDebugOption, seconds)
+	}
+	if _, err := c.Exec(ctx, []string{"sh", "-c", script}); err != nil {
+		return err
+	}
+	return c.Restart(ctx)
+}
@@ -0,0 +1,133 @@
+package nano
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the version of cn recorded in the metadata of the clusters it creates
+var Version = "undefined"
+
+// labels holding the metadata of a cluster
+const (
+	labelPort          = "io.ceph.nano.port"
+	labelContainerPort = "io.ceph.nano.container-port"
+	labelBindAddress   = "io.ceph.nano.bind-address"
+	labelHostname      = "io.ceph.nano.hostname"
+	labelWorkDir       = "io.ceph.nano.work-dir"
+	labelImage         = "io.ceph.nano.image"
+	labelVersion       = "io.ceph.nano.version"
+	labelCreated       = "io.ceph.nano.created"
+	labelMemory        = "io.ceph.nano.memory"
+	labelCPUs          = "io.ceph.nano.cpus"
+	labelSize          = "io.ceph.nano.size"
+	labelUser          = "io.ceph.nano.user"
+	labelClonedFrom    = "io.ceph.nano.cloned-from"
+	labelPersist       = "io.ceph.nano.persist"
+)
+
+// Metadata is what cn records about a cluster when it creates it
+// it is stored as labels of the container so it never depends on the order of its env or mounts
+type Metadata struct {
+	Port          string    // port the S3 gateway is published on
+	ContainerPort string    // port the S3 gateway listens on inside the container, Port unless the cluster is a clone
+	BindAddress   string    // host address the S3 gateway is published on
+	Hostname      string    // hostname of the container, the Ceph daemons are named after it
+	WorkDir       string    // host directory shared with the cluster
+	Image         string    // image reference the cluster was created from
+	Version       string    // version of cn that created the cluster
+	Created       time.Time // zero for clusters created before the metadata existed
+	Memory        int64     // memory limit in bytes
+	CPUs          float64   // number of CPUs
+	Size          int64     // size of the OSD backing store in bytes, 0 for the image default
+	User          string    // uid of the S3 user
+	ClonedFrom    string    // name of the cluster this one is a clone of, if any
+	Persist       bool      // the data lives in named volumes surviving the container
+}
+
+// labels returns the metadata as container labels
+func (m Metadata) labels() map[string]string {
+	return map[string]string{
+		labelPort:          m.Port,
+		labelContainerPort: m.ContainerPort,
+		labelBindAddress:   m.BindAddress,
+		labelHostname:      m.Hostname,
+		labelWorkDir:       m.WorkDir,
+		labelImage:         m.Image,
+		labelVersion:       m.Version,
+		labelCreated:       m.Created.UTC().Format(time.RFC3339),
+		labelMemory:        strconv.FormatInt(m.Memory, 10),
+		labelCPUs:          strconv.FormatFloat(m.CPUs, 'f', -1, 64),
+		labelSize:          strconv.FormatInt(m.Size, 10),
+		labelUser:          m.User,
+		labelClonedFrom:    m.ClonedFrom,
+		labelPersist:       strconv.FormatBool(m.Persist),
+	}
+}
+
+// readMetadata returns the metadata of a cluster from its container
+// this is the only place knowing where each piece of information lives
+func readMetadata(info ContainerInfo) Metadata {
+	labels := info.Labels
+	m := Metadata{
+		Port:          labels[labelPort],
+		ContainerPort: labels[labelContainerPort],
+		BindAddress:   labels[labelBindAddress],
+		Hostname:      labels[labelHostname],
+		WorkDir:       labels[labelWorkDir],
+		Image:         labels[labelImage],
+		Version:       labels[labelVersion],
+		User:          labels[labelUser],
+		ClonedFrom:    labels[labelClonedFrom],
+	}
+	m.Created, _ = time.Parse(time.RFC3339, labels[labelCreated])
+	m.Memory, _ = strconv.ParseInt(labels[labelMemory], 10, 64)
+	m.CPUs, _ = strconv.ParseFloat(labels[labelCPUs], 64)
+	m.Size, _ = strconv.ParseInt(labels[labelSize], 10, 64)
+	m.Persist, _ = strconv.ParseBool(labels[labelPersist])
+
+	// Clusters created before the labels existed, get what we can from the container itself
+	if m.Port == "" {
+		// The S3 gateway is the only port published
+		if len(info.Ports) > 0 {
+			m.Port = info.Ports[0].HostPort
+			m.BindAddress = info.Ports[0].HostIP
+		}
+		for _, env := range info.Env {
+			if m.Port == "" && strings.HasPrefix(env, "RGW_CIVETWEB_PORT=") {
+				m.Port = strings.TrimPrefix(env, "RGW_CIVETWEB_PORT=")
+			}
+		}
+	}
+	if m.ContainerPort == "" {
+		m.ContainerPort = m.Port
+	}
+	if m.BindAddress == "" {
+		m.BindAddress = DefaultBindAddress
+	}
+	if m.Hostname == "" {
+		m.Hostname = info.Name + hostnameSuffix
+	}
+	if m.WorkDir == "" {
+		for _, bind := range info.Binds {
+			parts := strings.Split(bind, ":")
+			if len(parts) > 1 && strings.TrimSuffix(parts[1], "/") == strings.TrimSuffix(TempPath, "/") {
+				m.WorkDir = parts[0]
+			}
+		}
+	}
+	if m.Image == "" {
+		m.Image = info.Image
+	}
+	if m.Memory == 0 {
+		m.Memory = info.Memory
+	}
+	if m.CPUs == 0 {
+		m.CPUs = float64(info.NanoCPUs) / 1e9
+	}
+	if m.User == "" {
+		m.User = UID
+	}
+	return m
+}
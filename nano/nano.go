@@ -0,0 +1,591 @@
+// Package nano manages Ceph Nano clusters, it is what the cn command line is built on.
+// Test suites can use it to get a throwaway S3 endpoint:
+//
+//	cluster, err := nano.Start(ctx, nano.Options{Name: "mytest"})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	t.Cleanup(func() { cluster.Purge() })
+//	s3Client, err := cluster.S3Client()
+package nano
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+const (
+	// ContainerNamePrefix is prepended to the cluster name to get its container name
+	ContainerNamePrefix = "ceph-nano-"
+
+	// DefaultImage is the container image used when none is given
+	DefaultImage = "ceph/daemon"
+
+	// DefaultWorkDir is the host directory mounted inside the container when none is given
+	DefaultWorkDir = "/usr/share/ceph-nano"
+
+	// UID is the uid of the S3 user created in every cluster
+	UID = "nano"
+
+	// TempPath is where the working directory is mounted inside the container
+	TempPath = "/tmp/"
+
+	// DefaultMemory is the memory limit of the container when none is given
+	DefaultMemory = 512 * 1024 * 1024
+
+	// MinMemory is the smallest memory limit the Ceph daemons can live with
+	MinMemory = 512 * 1024 * 1024
+
+	// DefaultCPUs is the number of CPUs the container can use when none is given
+	DefaultCPUs = 1.0
+
+	// MinSize is the smallest OSD backing store accepted
+	MinSize = 1024 * 1024 * 1024
+
+	// DefaultBindAddress is the address the S3 gateway is published on when none is given
+	DefaultBindAddress = "0.0.0.0"
+
+	// DefaultMinPort and DefaultMaxPort bound the ports scanned for the S3 gateway when none is given
+	DefaultMinPort = 8000
+	DefaultMaxPort = 8100
+
+	// hostnameSuffix is appended to the container name to get its hostname
+	hostnameSuffix = "-faa32aebf00b"
+)
+
+// dataPaths are the volumes of a cluster, they hold its configuration and its data
+var dataPaths = []string{"/etc/ceph", "/var/lib/ceph"}
+
+// Options describes the cluster to start
+type Options struct {
+	// Name of the cluster, mandatory
+	Name string
+
+	// Image is the container image to use, DefaultImage when empty
+	Image string
+
+	// WorkDir is the host directory shared with the cluster, DefaultWorkDir when empty
+	WorkDir string
+
+	// Privileged runs the container in privileged mode
+	Privileged bool
+
+	// Memory is the memory limit of the container in bytes, DefaultMemory when 0
+	Memory int64
+
+	// CPUs is the number of CPUs the container can use, fractions are allowed, DefaultCPUs when 0
+	CPUs float64
+
+	// Size is the size of the OSD backing store in bytes, the image default when 0
+	Size int64
+
+	// BindAddress is the host address the S3 gateway is published on, DefaultBindAddress when empty
+	BindAddress string
+
+	// Port pins the port of the S3 gateway, a free port between MinPort and MaxPort is used when 0
+	Port int
+
+	// MinPort and MaxPort bound the ports scanned, DefaultMinPort and DefaultMaxPort when 0
+	MinPort int
+	MaxPort int
+
+	// Users are S3 users created along with the default one, existing users are left as is
+	Users []User
+
+	// Buckets are created for the default S3 user, existing buckets are left as is
+	Buckets []string
+
+	// Persist keeps the data in named volumes, so the cluster can be purged with PurgeKeepData and started again
+	// with its data, e.g. on a new image
+	Persist bool
+
+	// Seed is a manifest of buckets and objects applied once the cluster is healthy, see Cluster.Seed
+	Seed *Manifest
+
+	// LifecycleDebugInterval makes a day of the lifecycle rules last this long, for testing, see SetLifecycleDebugInterval
+	LifecycleDebugInterval time.Duration
+
+	// Timeout bounds the wait for the cluster to be healthy, DefaultTimeout when 0
+	Timeout time.Duration
+
+	// NoWait returns as soon as the container is started, Users, Buckets, Seed and LifecycleDebugInterval
+	// can not be used with it
+	NoWait bool
+
+	// Runtime runs the container, NewRuntime("") is used when nil
+	Runtime Runtime
+
+	// Progress receives human readable progress messages, they are discarded when nil
+	Progress io.Writer
+}
+
+// Cluster is a Ceph Nano cluster and what is needed to talk to it
+type Cluster struct {
+	Name          string
+	ContainerName string
+	State         string // "created", "running" or "exited"
+	ImageID       string
+
+	// Metadata holds the work dir, image, resources and S3 user the cluster was created with
+	Metadata
+
+	// Endpoint is the URL of the S3 gateway, e.g: http://192.168.0.10:8000
+	// when the gateway is published on all the interfaces, the address of one of them is used
+	Endpoint string
+
+	// AccessKey and SecretKey are the S3 credentials of the cluster
+	// they are only known once the cluster is healthy, see Wait
+	AccessKey string
+	SecretKey string
+
+	runtime Runtime
+}
+
+// Start starts a cluster, creating it when it does not exist yet
+// it returns once the cluster is healthy and its S3 gateway answers, unless opts.NoWait is set
+func Start(ctx context.Context, opts Options) (*Cluster, error) {
+	if opts.Name == "" {
+		return nil, errors.New("a cluster name is required")
+	}
+	if opts.NoWait && (len(opts.Users) > 0 || len(opts.Buckets) > 0 || opts.Seed != nil || opts.LifecycleDebugInterval > 0) {
+		return nil, errors.New("users, buckets, seed and the lifecycle debug interval need a healthy cluster, they can not be used without waiting for it")
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	if opts.WorkDir == "" {
+		opts.WorkDir = DefaultWorkDir
+	}
+	if opts.Progress == nil {
+		opts.Progress = ioutil.Discard
+	}
+	if opts.Memory == 0 {
+		opts.Memory = DefaultMemory
+	}
+	if opts.CPUs == 0 {
+		opts.CPUs = DefaultCPUs
+	}
+	if opts.BindAddress == "" {
+		opts.BindAddress = DefaultBindAddress
+	}
+	if opts.MinPort == 0 && opts.MaxPort == 0 {
+		opts.MinPort, opts.MaxPort = DefaultMinPort, DefaultMaxPort
+	}
+	if err := validateResources(opts); err != nil {
+		return nil, err
+	}
+	if err := validateNetwork(opts); err != nil {
+		return nil, err
+	}
+	rt, err := runtimeOrDefault(opts.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	containerName := ContainerNamePrefix + opts.Name
+
+	// Test for a leftover container
+	// Usually happens when someone fails to run the container on an exposed directory
+	// Typical error on Docker For Mac you will see:
+	// panic: Error response from daemon: Mounts denied:
+	// The path /usr/share/ceph-nano is not shared from OS X and is not known to Docker.
+	// You can configure shared paths from Docker -> Preferences... -> File Sharing.
+	info, err := rt.InspectContainer(ctx, containerName)
+	if err == nil && info.State == "created" {
+		if err := rt.RemoveContainer(ctx, containerName, true); err != nil {
+			return nil, err
+		}
+		err = ErrNotFound
+	}
+
+	switch {
+	case err == ErrNotFound:
+		if err := pullImage(ctx, rt, opts.Image, opts.Progress); err != nil {
+			return nil, err
+		}
+		// The data of a persistent cluster purged with PurgeKeepData is brought back
+		if _, err := rt.InspectVolume(ctx, volumeName(containerName, dataPaths[0])); err == nil && !opts.Persist {
+			fmt.Fprintln(opts.Progress, "Reusing the data kept for cluster "+opts.Name+".")
+			opts.Persist = true
+		}
+		fmt.Fprintln(opts.Progress, "Running cluster "+opts.Name+"...")
+		if err := runContainer(ctx, rt, containerName, opts); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case info.State == "running":
+		fmt.Fprintln(opts.Progress, "Cluster "+opts.Name+" is already running!")
+	default:
+		fmt.Fprintln(opts.Progress, "Starting cluster "+opts.Name+"...")
+		if err := rt.StartContainer(ctx, containerName); err != nil {
+			return nil, err
+		}
+	}
+
+	cluster, err := Get(ctx, rt, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NoWait {
+		return cluster, nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	if err := cluster.WaitProgress(waitCtx, opts.Progress); err != nil {
+		return cluster, err
+	}
+	if opts.LifecycleDebugInterval > 0 {
+		fmt.Fprintln(opts.Progress, "Setting the lifecycle debug interval of cluster "+opts.Name+"...")
+		if err := cluster.SetLifecycleDebugInterval(ctx, opts.LifecycleDebugInterval); err != nil {
+			return cluster, err
+		}
+	}
+	if err := cluster.provision(ctx, opts); err != nil {
+		return cluster, err
+	}
+	if opts.Seed != nil {
+		fmt.Fprintln(opts.Progress, "Seeding cluster "+opts.Name+"...")
+		if err := cluster.Seed(ctx, opts.Seed); err != nil {
+			return cluster, err
+		}
+	}
+	return cluster, nil
+}
+
+// provision creates the users and buckets asked for a cluster
+func (c *Cluster) provision(ctx context.Context, opts Options) error {
+	for _, user := range opts.Users {
+		if _, err := c.CreateUser(ctx, user); err != nil {
+			return err
+		}
+	}
+	if len(opts.Buckets) == 0 {
+		return nil
+	}
+
+	s3Client, err := c.S3Client()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range opts.Buckets {
+		err := s3Client.MakeBucket(bucket, "")
+		if code := minio.ToErrorResponse(err).Code; code == "BucketAlreadyOwnedByYou" || code == "BucketAlreadyExists" {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to create bucket %s: %s", bucket, err)
+		}
+	}
+	return nil
+}
+
+// Get returns an existing cluster, ErrNotFound if there is none with this name
+// the S3 keys are not loaded, call Wait to make sure the cluster is ready and get them
+func Get(ctx context.Context, rt Runtime, name string) (*Cluster, error) {
+	rt, err := runtimeOrDefault(rt)
+	if err != nil {
+		return nil, err
+	}
+	info, err := rt.InspectContainer(ctx, ContainerNamePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	return newCluster(rt, info), nil
+}
+
+// List returns all the clusters, whatever their state
+// the S3 keys are not loaded
+func List(ctx context.Context, rt Runtime) ([]*Cluster, error) {
+	rt, err := runtimeOrDefault(rt)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := rt.ListContainers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []*Cluster
+	for _, container := range containers {
+		if strings.HasPrefix(container.Name, ContainerNamePrefix) {
+			clusters = append(clusters, newCluster(rt, container))
+		}
+	}
+	return clusters, nil
+}
+
+// newCluster fills a Cluster from what the runtime knows about its container
+func newCluster(rt Runtime, info ContainerInfo) *Cluster {
+	cluster := &Cluster{
+		Name:          strings.TrimPrefix(info.Name, ContainerNamePrefix),
+		ContainerName: info.Name,
+		State:         info.State,
+		ImageID:       info.ImageID,
+		Metadata:      readMetadata(info),
+		runtime:       rt,
+	}
+	if cluster.Port != "" {
+		cluster.Endpoint = "http://" + net.JoinHostPort(endpointAddress(cluster.BindAddress), cluster.Port)
+	}
+	return cluster
+}
+
+// runtimeOrDefault returns rt, or the default runtime when rt is nil
+func runtimeOrDefault(rt Runtime) (Runtime, error) {
+	if rt != nil {
+		return rt, nil
+	}
+	return NewRuntime("")
+}
+
+// Runtime returns the container runtime the cluster runs on
+func (c *Cluster) Runtime() Runtime {
+	return c.runtime
+}
+
+// Stop stops the cluster, its data is kept
+func (c *Cluster) Stop() error {
+	if err := c.runtime.StopContainer(context.Background(), c.ContainerName, 5*time.Second); err != nil {
+		return err
+	}
+	c.State = "exited"
+	return nil
+}
+
+// Restart restarts the cluster and waits for it to be healthy again
+func (c *Cluster) Restart(ctx context.Context) error {
+	if err := c.RestartContainer(ctx); err != nil {
+		return err
+	}
+	return c.Wait(ctx)
+}
+
+// RestartContainer restarts the cluster without waiting for it, see WaitProgress
+func (c *Cluster) RestartContainer(ctx context.Context) error {
+	if err := c.runtime.RestartContainer(ctx, c.ContainerName, 10*time.Second); err != nil {
+		return err
+	}
+	c.State = "running"
+	return nil
+}
+
+// Purge removes the cluster and all its data, purging a cluster that is already gone is not an error
+func (c *Cluster) Purge() error {
+	if err := c.PurgeKeepData(); err != nil {
+		return err
+	}
+	if c.Persist {
+		return removeVolumes(context.Background(), c.runtime, c.ContainerName)
+	}
+	return nil
+}
+
+// PurgeKeepData removes the cluster but keeps the named volumes of a persistent cluster,
+// starting a persistent cluster with the same name brings its data back
+// the volumes of other clusters are removed with the container, upgraded ones included: an upgrade gives them by name
+func (c *Cluster) PurgeKeepData() error {
+	ctx := context.Background()
+	var volumes []string
+	if !c.Persist {
+		info, err := c.runtime.InspectContainer(ctx, c.ContainerName)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		for _, mount := range info.Mounts {
+			if mount.Type == "volume" && mount.Name != "" {
+				volumes = append(volumes, mount.Name)
+			}
+		}
+	}
+	err := c.runtime.RemoveContainer(ctx, c.ContainerName, true)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	// The runtime only removes the volumes it created unnamed, those already gone are not an error
+	for _, name := range volumes {
+		if err := c.runtime.RemoveVolume(ctx, name); err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exec runs a command inside the cluster container and returns its output
+// a command exiting with a non-zero code is reported as an error along with its output
+func (c *Cluster) Exec(ctx context.Context, cmd []string) ([]byte, error) {
+	output, exitCode, err := c.runtime.ExecContainer(ctx, c.ContainerName, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return output, fmt.Errorf("'%s' exited with code %d: %s", strings.Join(cmd, " "), exitCode, strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
+
+// CephHealth returns the output of 'ceph health'
+func (c *Cluster) CephHealth(ctx context.Context) (string, error) {
+	output, err := c.Exec(ctx, []string{"ceph", "health"})
+	return strings.TrimSpace(string(output)), err
+}
+
+// S3Logs returns the logs of the Rados Gateway
+func (c *Cluster) S3Logs(ctx context.Context) ([]byte, error) {
+	return c.Exec(ctx, []string{"cat", "/var/log/ceph/client.rgw." + c.Hostname + ".log"})
+}
+
+// S3Client returns an S3 client using the credentials of the cluster
+func (c *Cluster) S3Client() (*minio.Client, error) {
+	endpoint, err := c.S3Endpoint(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.Client()
+}
+
+// loadKeys reads the S3 keys of the cluster from inside the container
+// a container recreated on existing volumes may have no user details, the keys are then asked to radosgw-admin
+func (c *Cluster) loadKeys(ctx context.Context) error {
+	output, err := c.Exec(ctx, []string{"cat", userDetailsPath})
+	if err != nil {
+		user, userErr := c.GetUser(ctx, c.User)
+		if userErr != nil {
+			return err
+		}
+		c.AccessKey, c.SecretKey = user.AccessKey, user.SecretKey
+		return nil
+	}
+
+	var details struct {
+		Keys []struct {
+			AccessKey string `json:"Access_key"`
+			SecretKey string `json:"Secret_key"`
+		}
+	}
+	if err := json.Unmarshal(output, &details); err != nil {
+		return fmt.Errorf("unable to read the S3 keys of cluster %s: %s", c.Name, err)
+	}
+	if len(details.Keys) == 0 {
+		return errors.New("cluster " + c.Name + " has no S3 keys")
+	}
+	c.AccessKey = details.Keys[0].AccessKey
+	c.SecretKey = details.Keys[0].SecretKey
+	return nil
+}
+
+// runContainer creates and starts a new container
+func runContainer(ctx context.Context, rt Runtime, containerName string, opts Options) error {
+	rgwPort, err := generateRGWPortToUse(opts)
+	if err != nil {
+		return err
+	}
+
+	metadata := Metadata{
+		Port:          rgwPort,
+		ContainerPort: rgwPort,
+		BindAddress:   opts.BindAddress,
+		Hostname:      containerName + hostnameSuffix,
+		WorkDir:       opts.WorkDir,
+		Image:         opts.Image,
+		Version:       Version,
+		Created:       time.Now(),
+		Memory:        opts.Memory,
+		CPUs:          opts.CPUs,
+		Size:          opts.Size,
+		User:          UID,
+		Persist:       opts.Persist,
+	}
+	if metadata.Persist {
+		if err := prepareVolumes(ctx, rt, containerName, &metadata); err != nil {
+			return err
+		}
+	}
+	if err := rt.CreateContainer(ctx, metadata.containerSpec(containerName, opts.Privileged)); err != nil {
+		return err
+	}
+	return rt.StartContainer(ctx, containerName)
+}
+
+// containerSpec returns the container of a cluster created with this metadata
+func (m Metadata) containerSpec(containerName string, privileged bool) ContainerSpec {
+	envs := []string{
+		"RGW_CIVETWEB_PORT=" + m.ContainerPort,
+		"DEBUG=verbose",
+		"CEPH_DEMO_UID=" + m.User,
+		"NETWORK_AUTO_DETECT=4",
+		"CEPH_DAEMON=demo",
+		"DEMO_DAEMONS=mon,mgr,osd,rgw"}
+	if m.Size > 0 {
+		envs = append(envs, "BLUESTORE_BLOCK_SIZE="+strconv.FormatInt(m.Size, 10))
+	}
+
+	volumes := dataPaths
+	if m.Persist {
+		volumes = nil
+		for _, containerPath := range dataPaths {
+			volumes = append(volumes, volumeName(containerName, containerPath)+":"+containerPath)
+		}
+	}
+
+	return ContainerSpec{
+		Name:     containerName,
+		Image:    m.Image,
+		Hostname: m.Hostname,
+		Env:      envs,
+		Labels:   m.labels(),
+		Volumes:  volumes,
+		Binds:    []string{m.WorkDir + ":" + TempPath},
+		Ports: []PortBinding{
+			{
+				HostIP:        m.BindAddress,
+				HostPort:      m.Port,
+				ContainerPort: m.ContainerPort,
+			},
+		},
+		Memory:     m.Memory,
+		NanoCPUs:   int64(m.CPUs * 1e9),
+		Privileged: privileged,
+	}
+}
+
+// validateResources checks the resources asked for a new cluster
+func validateResources(opts Options) error {
+	if opts.Memory < MinMemory {
+		return fmt.Errorf("the memory limit must be at least %d MiB", MinMemory/1024/1024)
+	}
+	// The upper bound is checked by the runtime, it may run on another machine than cn
+	if opts.CPUs < 0.01 {
+		return errors.New("the number of CPUs must be at least 0.01")
+	}
+	if opts.Size != 0 && opts.Size < MinSize {
+		return fmt.Errorf("the OSD size must be at least %d GiB", MinSize/1024/1024/1024)
+	}
+	return nil
+}
+
+// pullImage downloads the container image if it is not present yet
+func pullImage(ctx context.Context, rt Runtime, image string, progress io.Writer) error {
+	_, err := rt.InspectImage(ctx, image)
+	if err != ErrNotFound {
+		return err
+	}
+
+	fmt.Fprint(progress, "The container image is not present, pulling it. \n"+
+		"This operation can take a few minutes.")
+	err = rt.PullImage(ctx, image, progress)
+	fmt.Fprintln(progress)
+	return err
+}
@@ -0,0 +1,120 @@
+package nano
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// byLastOctetValue implements sort.Interface used in sorting a list
+// of ip address by their last octet value.
+type byLastOctetValue []net.IP
+
+func (n byLastOctetValue) Len() int      { return len(n) }
+func (n byLastOctetValue) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+func (n byLastOctetValue) Less(i, j int) bool {
+	return []byte(n[i].To4())[3] < []byte(n[j].To4())[3]
+}
+
+// getInterfaceIPv4s is synonymous to net.InterfaceAddrs()
+// returns net.IP IPv4 only representation of the net.Addr.
+// Additionally the returned list is sorted by their last
+// octet value.
+//
+// [The logic to sort by last octet is implemented to
+// prefer CIDRs with higher octets, this in-turn skips the
+// localhost/loopback address to be not preferred as the
+// first ip on the list. Subsequently this list helps us print
+// a user friendly message with appropriate values].
+func getInterfaceIPv4s() ([]net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to determine network interface address. %s", err)
+	}
+	// Go through each return network address and collate IPv4 addresses.
+	var nips []net.IP
+	for _, addr := range addrs {
+		if addr.Network() == "ip+net" {
+			var nip net.IP
+			// Attempt to parse the addr through CIDR.
+			nip, _, err = net.ParseCIDR(addr.String())
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse address %s, error %s", addr, err)
+			}
+			// Collect only IPv4 addrs.
+			if nip.To4() != nil {
+				nips = append(nips, nip)
+			}
+		}
+	}
+	// Sort the list of IPs by their last octet value.
+	sort.Sort(sort.Reverse(byLastOctetValue(nips)))
+	return nips, nil
+}
+
+// portAvailable checks if a port is free on a given address
+// the port must be bindable and nothing must answer on it, ports published
+// through iptables only can be bound while being in use
+func portAvailable(bindAddress string, port int) bool {
+	address := net.JoinHostPort(bindAddress, strconv.Itoa(port))
+
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err == nil {
+		conn.Close()
+		return false
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// generateRGWPortToUse finds the binding port for Ceph Rados Gateway
+// a fixed port is only checked, otherwise the first free port of the range is used
+func generateRGWPortToUse(opts Options) (string, error) {
+	if opts.Port != 0 {
+		if !portAvailable(opts.BindAddress, opts.Port) {
+			return "", fmt.Errorf("port %d is already in use on %s, please use another --port", opts.Port, opts.BindAddress)
+		}
+		return strconv.Itoa(opts.Port), nil
+	}
+	for port := opts.MinPort; port <= opts.MaxPort; port++ {
+		if portAvailable(opts.BindAddress, port) {
+			return strconv.Itoa(port), nil
+		}
+	}
+	return "", fmt.Errorf("unable to find a free port between %d and %d on %s", opts.MinPort, opts.MaxPort, opts.BindAddress)
+}
+
+// validateNetwork checks the address and ports asked for a new cluster
+func validateNetwork(opts Options) error {
+	if net.ParseIP(opts.BindAddress) == nil || net.ParseIP(opts.BindAddress).To4() == nil {
+		return fmt.Errorf("invalid bind address %q, an IPv4 address is expected", opts.BindAddress)
+	}
+	if opts.Port < 0 || opts.Port > 65535 {
+		return fmt.Errorf("invalid port %d", opts.Port)
+	}
+	if opts.MinPort < 1 || opts.MaxPort > 65535 || opts.MinPort > opts.MaxPort {
+		return fmt.Errorf("invalid port range %d-%d", opts.MinPort, opts.MaxPort)
+	}
+	return nil
+}
+
+// endpointAddress returns the address clients should use to reach a port bound on bindAddress
+// a port bound on all the interfaces is reachable through any of them
+func endpointAddress(bindAddress string) string {
+	if bindAddress != "" && bindAddress != "0.0.0.0" {
+		return bindAddress
+	}
+	// Using the first IP of the list is not ideal
+	// However, the port is bound on 0.0.0.0 so any address will work
+	if ips, err := getInterfaceIPv4s(); err == nil && len(ips) > 0 {
+		return ips[0].String()
+	}
+	return "127.0.0.1"
+}
@@ -0,0 +1,36 @@
+package nano
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/minio/minio-go"
+)
+
+// SetBucketPolicy replaces the JSON policy of a bucket
+func (e *S3Endpoint) SetBucketPolicy(ctx context.Context, bucket string, policy []byte) error {
+	if !json.Valid(policy) {
+		return errors.New("invalid bucket policy, it is not JSON")
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	_, err := e.request(ctx, http.MethodPut, bucket, "", url.Values{"policy": {""}}, header, policy)
+	return err
+}
+
+// GetBucketPolicy returns the JSON policy of a bucket, nil when it has none
+func (e *S3Endpoint) GetBucketPolicy(ctx context.Context, bucket string) ([]byte, error) {
+	policy, err := e.request(ctx, http.MethodGet, bucket, "", url.Values{"policy": {""}}, nil, nil)
+	if minio.ToErrorResponse(err).Code == "NoSuchBucketPolicy" {
+		return nil, nil
+	}
+	return policy, err
+}
+
+// RemoveBucketPolicy removes the policy of a bucket
+func (e *S3Endpoint) RemoveBucketPolicy(ctx context.Context, bucket string) error {
+	_, err := e.request(ctx, http.MethodDelete, bucket, "", url.Values{"policy": {""}}, nil, nil)
+	return err
+}
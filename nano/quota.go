@@ -0,0 +1,157 @@
+package nano
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// scopes of the quotas
+const (
+	QuotaUser   = "user"
+	QuotaBucket = "bucket"
+)
+
+// Quota limits the data of a user or bucket, a negative limit is no limit
+// the Rados Gateway only enforces an enabled quota
+type Quota struct {
+	Enabled    bool
+	MaxSize    int64 // bytes
+	MaxObjects int64
+}
+
+// QuotaUsage is the quota of a user or bucket along with what the user or bucket uses
+type QuotaUsage struct {
+	Quota
+	Size    int64
+	Objects int64
+}
+
+// radosgwQuota is a quota as radosgw-admin prints it
+type radosgwQuota struct {
+	Enabled    bool  `json:"enabled"`
+	MaxSize    int64 `json:"max_size"`
+	MaxObjects int64 `json:"max_objects"`
+}
+
+// radosgwUsage is the usage of a user or bucket, older releases count in KiB and name the fields differently
+type radosgwUsage struct {
+	Size         int64 `json:"size"`
+	SizeKB       int64 `json:"size_kb"`
+	TotalBytes   int64 `json:"total_bytes"`
+	NumObjects   int64 `json:"num_objects"`
+	TotalEntries int64 `json:"total_entries"`
+}
+
+func (u radosgwUsage) size() int64 {
+	switch {
+	case u.Size > 0:
+		return u.Size
+	case u.TotalBytes > 0:
+		return u.TotalBytes
+	}
+	return u.SizeKB * 1024
+}
+
+func (u radosgwUsage) objects() int64 {
+	if u.NumObjects > 0 {
+		return u.NumObjects
+	}
+	return u.TotalEntries
+}
+
+// radosgwBucketStats is the part of the 'radosgw-admin bucket stats' output cn uses
+type radosgwBucketStats struct {
+	Owner       string                  `json:"owner"`
+	BucketQuota radosgwQuota            `json:"bucket_quota"`
+	Usage       map[string]radosgwUsage `json:"usage"`
+}
+
+// quotaArgs returns the radosgw-admin arguments selecting the quota of a user or bucket
+func quotaArgs(scope string, name string) ([]string, error) {
+	switch scope {
+	case QuotaUser:
+		return []string{"--quota-scope=user", "--uid=" + name}, nil
+	case QuotaBucket:
+		return []string{"--quota-scope=bucket", "--bucket=" + name}, nil
+	}
+	return nil, fmt.Errorf("invalid quota scope %q, it is %s or %s", scope, QuotaUser, QuotaBucket)
+}
+
+// SetQuota sets the quota of a user or bucket, name is the UID of the user or the name of the bucket
+func (c *Cluster) SetQuota(ctx context.Context, scope string, name string, quota Quota) error {
+	selector, err := quotaArgs(scope, name)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"quota", "set"}, selector...)
+	args = append(args, "--max-size="+strconv.FormatInt(quota.MaxSize, 10), "--max-objects="+strconv.FormatInt(quota.MaxObjects, 10))
+	if err := c.radosgwAdmin(ctx, nil, args...); err != nil {
+		return err
+	}
+
+	toggle := "disable"
+	if quota.Enabled {
+		toggle = "enable"
+	}
+	return c.radosgwAdmin(ctx, nil, append([]string{"quota", toggle}, selector...)...)
+}
+
+// ClearQuota removes the limits of the quota of a user or bucket and disables it
+func (c *Cluster) ClearQuota(ctx context.Context, scope string, name string) error {
+	return c.SetQuota(ctx, scope, name, Quota{MaxSize: -1, MaxObjects: -1})
+}
+
+// GetQuota returns the quota of a user or bucket along with its usage
+func (c *Cluster) GetQuota(ctx context.Context, scope string, name string) (QuotaUsage, error) {
+	if _, err := quotaArgs(scope, name); err != nil {
+		return QuotaUsage{}, err
+	}
+
+	if scope == QuotaBucket {
+		stats, err := c.bucketStats(ctx, name)
+		if err != nil {
+			return QuotaUsage{}, err
+		}
+		usage := QuotaUsage{Quota: stats.BucketQuota.toQuota()}
+		for _, category := range stats.Usage {
+			usage.Size += category.size()
+			usage.Objects += category.objects()
+		}
+		return usage, nil
+	}
+
+	var user struct {
+		UserQuota radosgwQuota `json:"user_quota"`
+	}
+	if err := c.radosgwAdmin(ctx, &user, "user", "info", "--uid="+name); err != nil {
+		return QuotaUsage{}, err
+	}
+	var stats struct {
+		Stats radosgwUsage `json:"stats"`
+	}
+	if err := c.radosgwAdmin(ctx, &stats, "user", "stats", "--uid="+name, "--sync-stats"); err != nil {
+		return QuotaUsage{}, err
+	}
+	return QuotaUsage{Quota: user.UserQuota.toQuota(), Size: stats.Stats.size(), Objects: stats.Stats.objects()}, nil
+}
+
+// BucketOwner returns the UID of the user owning a bucket
+func (c *Cluster) BucketOwner(ctx context.Context, bucket string) (string, error) {
+	stats, err := c.bucketStats(ctx, bucket)
+	if err != nil {
+		return "", err
+	}
+	return stats.Owner, nil
+}
+
+// bucketStats returns the owner, quota and usage of a bucket
+func (c *Cluster) bucketStats(ctx context.Context, bucket string) (radosgwBucketStats, error) {
+	var stats radosgwBucketStats
+	err := c.radosgwAdmin(ctx, &stats, "bucket", "stats", "--bucket="+bucket)
+	return stats, err
+}
+
+func (q radosgwQuota) toQuota() Quota {
+	return Quota{Enabled: q.Enabled, MaxSize: q.MaxSize, MaxObjects: q.MaxObjects}
+}
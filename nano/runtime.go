@@ -0,0 +1,161 @@
+package nano
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// ErrNotFound is returned by the runtimes when a container or an image does not exist
+var ErrNotFound = errors.New("no such container or image")
+
+// PortBinding publishes a port of the container on the host
+type PortBinding struct {
+	HostIP        string
+	HostPort      string
+	ContainerPort string
+}
+
+// ContainerMount is a volume or a bind mount of a container
+type ContainerMount struct {
+	Type        string // "volume" or "bind"
+	Name        string // volume name, empty for bind mounts
+	Source      string
+	Destination string
+}
+
+// ContainerSpec describes a container to create
+type ContainerSpec struct {
+	Name       string
+	Image      string
+	Hostname   string
+	Env        []string
+	Labels     map[string]string
+	Volumes    []string // destinations of anonymous volumes, or 'name:destination' for named ones
+	Binds      []string // 'host_dir:container_dir'
+	Ports      []PortBinding
+	Memory     int64 // bytes
+	NanoCPUs   int64 // billionths of a CPU
+	Privileged bool
+}
+
+// ContainerInfo is what cn needs to know about an existing container
+type ContainerInfo struct {
+	ID         string
+	Name       string    // without the leading '/' Docker adds
+	Image      string    // image reference the container was created from
+	ImageID    string    // without the 'sha256:' prefix
+	State      string    // "created", "running" or "exited"
+	StartedAt  time.Time // last time the container was started, zero if it never was
+	Env        []string
+	Labels     map[string]string
+	Binds      []string
+	Ports      []PortBinding
+	Mounts     []ContainerMount
+	Memory     int64
+	NanoCPUs   int64
+	Privileged bool
+}
+
+// VolumeInfo is what cn needs to know about a named volume
+type VolumeInfo struct {
+	Name       string
+	Labels     map[string]string
+	Mountpoint string
+}
+
+// ImageInfo is what cn needs to know about a container image
+type ImageInfo struct {
+	ID          string
+	RepoTags    []string
+	RepoDigests []string
+	Created     string
+	Labels      map[string]string
+}
+
+// Runtime is the set of operations cn performs on containers and images
+// it hides whether they are handled by Docker or by Podman
+type Runtime interface {
+	// Name returns the name of the runtime, as given to --runtime
+	Name() string
+
+	CreateContainer(ctx context.Context, spec ContainerSpec) error
+	StartContainer(ctx context.Context, name string) error
+	StopContainer(ctx context.Context, name string, timeout time.Duration) error
+	RestartContainer(ctx context.Context, name string, timeout time.Duration) error
+	RemoveContainer(ctx context.Context, name string, removeVolumes bool) error
+	InspectContainer(ctx context.Context, name string) (ContainerInfo, error)
+	ListContainers(ctx context.Context, all bool) ([]ContainerInfo, error)
+
+	// ExecContainer runs a command inside a running container
+	// it returns stdout and stderr combined along with the exit code of the command
+	ExecContainer(ctx context.Context, name string, cmd []string) ([]byte, int, error)
+
+	// CopyFromContainer returns a tar archive of a path of the container, which does not need to run
+	CopyFromContainer(ctx context.Context, name string, path string) (io.ReadCloser, error)
+
+	// CopyToContainer extracts a tar archive into a directory of the container, which does not need to run
+	CopyToContainer(ctx context.Context, name string, dir string, archive io.Reader) error
+
+	// ContainerLogs returns the stdout of the container, already demultiplexed, starting at since unless it is zero
+	// when follow is set, the reader blocks waiting for new logs until ctx is done
+	ContainerLogs(ctx context.Context, name string, follow bool, since time.Time) (io.ReadCloser, error)
+
+	// CreateVolume creates a named volume, an existing volume is kept as is
+	CreateVolume(ctx context.Context, name string, labels map[string]string) error
+	InspectVolume(ctx context.Context, name string) (VolumeInfo, error)
+	ListVolumes(ctx context.Context) ([]VolumeInfo, error)
+	RemoveVolume(ctx context.Context, name string) error
+
+	// PullImage pulls an image, progress is written a '.' per event received
+	PullImage(ctx context.Context, image string, progress io.Writer) error
+	InspectImage(ctx context.Context, image string) (ImageInfo, error)
+	RemoveImage(ctx context.Context, image string) error
+}
+
+// NewRuntime returns the container runtime called name, "docker" or "podman"
+// when name is empty, CN_RUNTIME is used and Docker is the default
+func NewRuntime(name string) (Runtime, error) {
+	if name == "" {
+		name = os.Getenv("CN_RUNTIME")
+	}
+	switch strings.ToLower(name) {
+	case "", "docker":
+		docker, err := newDockerRuntime()
+		if err != nil {
+			return nil, err
+		}
+		return docker, nil
+	case "podman":
+		return newPodmanRuntime(), nil
+	}
+	return nil, fmt.Errorf("unknown container runtime '%s', please use 'docker' or 'podman'", name)
+}
+
+// demuxLogs turns a multiplexed stdout/stderr stream into a plain one
+// the returned reader is closed along with the original stream
+func demuxLogs(stream io.ReadCloser) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(writer, writer, stream)
+		writer.CloseWithError(err)
+	}()
+	return &demuxedLogs{PipeReader: reader, stream: stream}
+}
+
+// demuxedLogs closes both the pipe and the multiplexed stream it reads from
+type demuxedLogs struct {
+	*io.PipeReader
+	stream io.ReadCloser
+}
+
+func (d *demuxedLogs) Close() error {
+	d.PipeReader.Close()
+	return d.stream.Close()
+}
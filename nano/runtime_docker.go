@@ -0,0 +1,350 @@
+package nano
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+// dockerRuntime runs containers with Docker
+type dockerRuntime struct {
+	cli *client.Client
+}
+
+// newDockerRuntime connects to the Docker daemon, degrading the API version if needed
+func newDockerRuntime() (*dockerRuntime, error) {
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Let's make a first Docker command to check if the protocol is consistent
+	var apiVersion string
+	_, err = cli.Info(context.Background())
+	if err != nil {
+		// Oops, unable to handle server's protocol
+		serverVersion := fmt.Sprint(err)
+		if strings.Contains(serverVersion, "is too new") {
+			ss := strings.SplitAfter(serverVersion, "Maximum supported API version is ")
+			apiVersion = ss[1]
+		} else if strings.Contains(serverVersion, "client is newer than server") {
+			ss := strings.SplitAfter(serverVersion, "server API version: ")
+			// trim last character since this 'ss[1]' is '1.24.'
+			apiVersion = ss[1][:len(ss[1])-1]
+		} else {
+			// That's an error we don't know, let's stop here
+			return nil, err
+		}
+
+		// The client version shall be degraded as it's greater than the server's one
+		if len(apiVersion) > 0 {
+			os.Setenv("DOCKER_API_VERSION", apiVersion)
+			fmt.Fprintln(os.Stderr, "Warning: Degrading Docker client API version to "+apiVersion+" to match server's version")
+			// As the DOCKER_API_VERSION variable is updated, we have to restart the communication to get it
+			return newDockerRuntime()
+		}
+	}
+	// Ok, the Docker connection is valid & functional
+	return &dockerRuntime{cli: cli}, nil
+}
+
+func (d *dockerRuntime) Name() string {
+	return "docker"
+}
+
+func (d *dockerRuntime) CreateContainer(ctx context.Context, spec ContainerSpec) error {
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, port := range spec.Ports {
+		natPort := nat.Port(port.ContainerPort + "/tcp")
+		exposedPorts[natPort] = struct{}{}
+		portBindings[natPort] = append(portBindings[natPort], nat.PortBinding{
+			HostIP:   port.HostIP,
+			HostPort: port.HostPort,
+		})
+	}
+
+	// Named volumes are given as binds, Docker tells them from host directories by their name
+	volumes := map[string]struct{}{}
+	binds := append([]string{}, spec.Binds...)
+	for _, volume := range spec.Volumes {
+		if strings.Contains(volume, ":") {
+			binds = append(binds, volume)
+		} else {
+			volumes[volume] = struct{}{}
+		}
+	}
+
+	config := &container.Config{
+		Image:        spec.Image,
+		Hostname:     spec.Hostname,
+		ExposedPorts: exposedPorts,
+		Env:          spec.Env,
+		Labels:       spec.Labels,
+		Volumes:      volumes,
+	}
+
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
+		Binds:        binds,
+		Resources: container.Resources{
+			Memory:   spec.Memory,
+			NanoCPUs: spec.NanoCPUs,
+		},
+		Privileged: spec.Privileged,
+	}
+
+	_, err := d.cli.ContainerCreate(ctx, config, hostConfig, nil, spec.Name)
+	return d.convertError(err)
+}
+
+func (d *dockerRuntime) StartContainer(ctx context.Context, name string) error {
+	return d.convertError(d.cli.ContainerStart(ctx, name, types.ContainerStartOptions{}))
+}
+
+func (d *dockerRuntime) StopContainer(ctx context.Context, name string, timeout time.Duration) error {
+	return d.convertError(d.cli.ContainerStop(ctx, name, &timeout))
+}
+
+func (d *dockerRuntime) RestartContainer(ctx context.Context, name string, timeout time.Duration) error {
+	return d.convertError(d.cli.ContainerRestart(ctx, name, &timeout))
+}
+
+func (d *dockerRuntime) RemoveContainer(ctx context.Context, name string, removeVolumes bool) error {
+	options := types.ContainerRemoveOptions{
+		RemoveLinks:   false,
+		RemoveVolumes: removeVolumes,
+		Force:         true,
+	}
+	return d.convertError(d.cli.ContainerRemove(ctx, name, options))
+}
+
+func (d *dockerRuntime) InspectContainer(ctx context.Context, name string) (ContainerInfo, error) {
+	inspect, err := d.cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return ContainerInfo{}, d.convertError(err)
+	}
+
+	info := ContainerInfo{
+		ID:         inspect.ID,
+		Name:       strings.TrimPrefix(inspect.Name, "/"),
+		Image:      inspect.Config.Image,
+		ImageID:    strings.TrimPrefix(inspect.Image, "sha256:"),
+		State:      inspect.State.Status,
+		Env:        inspect.Config.Env,
+		Labels:     inspect.Config.Labels,
+		Binds:      inspect.HostConfig.Binds,
+		Memory:     inspect.HostConfig.Memory,
+		NanoCPUs:   inspect.HostConfig.NanoCPUs,
+		Privileged: inspect.HostConfig.Privileged,
+	}
+	// Docker gives the zero time when the container never started, which parses as such
+	info.StartedAt, _ = time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+	for port, bindings := range inspect.HostConfig.PortBindings {
+		for _, binding := range bindings {
+			info.Ports = append(info.Ports, PortBinding{
+				HostIP:        binding.HostIP,
+				HostPort:      binding.HostPort,
+				ContainerPort: strings.TrimSuffix(string(port), "/tcp"),
+			})
+		}
+	}
+	for _, mount := range inspect.Mounts {
+		info.Mounts = append(info.Mounts, ContainerMount{
+			Type:        string(mount.Type),
+			Name:        mount.Name,
+			Source:      mount.Source,
+			Destination: mount.Destination,
+		})
+	}
+	return info, nil
+}
+
+func (d *dockerRuntime) ListContainers(ctx context.Context, all bool) ([]ContainerInfo, error) {
+	listOptions := types.ContainerListOptions{
+		All:   all,
+		Quiet: true,
+	}
+	containers, err := d.cli.ContainerList(ctx, listOptions)
+	if err != nil {
+		return nil, d.convertError(err)
+	}
+
+	var infos []ContainerInfo
+	for _, c := range containers {
+		// Docker returns every name of the container, we only name ours once
+		if len(c.Names) == 0 {
+			continue
+		}
+		info := ContainerInfo{
+			ID:      c.ID,
+			Name:    strings.TrimPrefix(c.Names[0], "/"),
+			Image:   c.Image,
+			ImageID: strings.TrimPrefix(c.ImageID, "sha256:"),
+			State:   c.State,
+			Labels:  c.Labels,
+		}
+		for _, port := range c.Ports {
+			info.Ports = append(info.Ports, PortBinding{
+				HostIP:        port.IP,
+				HostPort:      fmt.Sprint(port.PublicPort),
+				ContainerPort: fmt.Sprint(port.PrivatePort),
+			})
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (d *dockerRuntime) ExecContainer(ctx context.Context, name string, cmd []string) ([]byte, int, error) {
+	optionsCreate := types.ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          cmd,
+	}
+
+	response, err := d.cli.ContainerExecCreate(ctx, name, optionsCreate)
+	if err != nil {
+		return nil, 0, d.convertError(err)
+	}
+
+	optionsAttach := types.ExecStartCheck{
+		Detach: false,
+		Tty:    false,
+	}
+	connection, err := d.cli.ContainerExecAttach(ctx, response.ID, optionsAttach)
+	if err != nil {
+		return nil, 0, d.convertError(err)
+	}
+	defer connection.Close()
+
+	// The stream is multiplexed, each frame carries a header telling if it belongs to stdout or stderr
+	// Demultiplex it so we only return the actual content of both streams
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, connection.Reader); err != nil {
+		return nil, 0, err
+	}
+
+	inspect, err := d.cli.ContainerExecInspect(ctx, response.ID)
+	if err != nil {
+		return nil, 0, d.convertError(err)
+	}
+	return output.Bytes(), inspect.ExitCode, nil
+}
+
+func (d *dockerRuntime) CopyFromContainer(ctx context.Context, name string, path string) (io.ReadCloser, error) {
+	archive, _, err := d.cli.CopyFromContainer(ctx, name, path)
+	return archive, d.convertError(err)
+}
+
+func (d *dockerRuntime) CopyToContainer(ctx context.Context, name string, dir string, archive io.Reader) error {
+	return d.convertError(d.cli.CopyToContainer(ctx, name, dir, archive, types.CopyToContainerOptions{}))
+}
+
+func (d *dockerRuntime) ContainerLogs(ctx context.Context, name string, follow bool, since time.Time) (io.ReadCloser, error) {
+	options := types.ContainerLogsOptions{ShowStdout: true, Follow: follow}
+	if !since.IsZero() {
+		options.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	}
+	out, err := d.cli.ContainerLogs(ctx, name, options)
+	if err != nil {
+		return nil, d.convertError(err)
+	}
+	return demuxLogs(out), nil
+}
+
+func (d *dockerRuntime) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	_, err := d.cli.VolumeCreate(ctx, volume.VolumesCreateBody{Name: name, Labels: labels})
+	return d.convertError(err)
+}
+
+func (d *dockerRuntime) InspectVolume(ctx context.Context, name string) (VolumeInfo, error) {
+	v, err := d.cli.VolumeInspect(ctx, name)
+	if err != nil {
+		return VolumeInfo{}, d.convertError(err)
+	}
+	return VolumeInfo{Name: v.Name, Labels: v.Labels, Mountpoint: v.Mountpoint}, nil
+}
+
+func (d *dockerRuntime) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	list, err := d.cli.VolumeList(ctx, filters.NewArgs())
+	if err != nil {
+		return nil, d.convertError(err)
+	}
+	var volumes []VolumeInfo
+	for _, v := range list.Volumes {
+		volumes = append(volumes, VolumeInfo{Name: v.Name, Labels: v.Labels, Mountpoint: v.Mountpoint})
+	}
+	return volumes, nil
+}
+
+func (d *dockerRuntime) RemoveVolume(ctx context.Context, name string) error {
+	return d.convertError(d.cli.VolumeRemove(ctx, name, false))
+}
+
+func (d *dockerRuntime) PullImage(ctx context.Context, image string, progress io.Writer) error {
+	out, err := d.cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return d.convertError(err)
+	}
+	defer out.Close()
+
+	reader := bufio.NewReader(out)
+	for {
+		_, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(progress, ".")
+	}
+}
+
+func (d *dockerRuntime) InspectImage(ctx context.Context, image string) (ImageInfo, error) {
+	i, _, err := d.cli.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return ImageInfo{}, d.convertError(err)
+	}
+	info := ImageInfo{
+		ID:          strings.TrimPrefix(i.ID, "sha256:"),
+		RepoTags:    i.RepoTags,
+		RepoDigests: i.RepoDigests,
+		Created:     i.Created,
+	}
+	if i.ContainerConfig != nil {
+		info.Labels = i.ContainerConfig.Labels
+	}
+	return info, nil
+}
+
+func (d *dockerRuntime) RemoveImage(ctx context.Context, image string) error {
+	options := types.ImageRemoveOptions{
+		Force:         true,
+		PruneChildren: true,
+	}
+	_, err := d.cli.ImageRemove(ctx, image, options)
+	return d.convertError(err)
+}
+
+// convertError maps Docker's not found errors to ErrNotFound
+func (d *dockerRuntime) convertError(err error) error {
+	if err != nil && client.IsErrNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
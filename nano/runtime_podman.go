@@ -0,0 +1,497 @@
+package nano
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// podmanAPIPrefix is the versioned prefix of the libpod REST API
+const podmanAPIPrefix = "/v3.0.0/libpod"
+
+// podmanRuntime runs containers with Podman, through the REST API socket of 'podman system service'
+type podmanRuntime struct {
+	socket string
+	http   *http.Client
+}
+
+// newPodmanRuntime returns a runtime talking to the Podman socket
+// the socket is taken from CN_PODMAN_SOCKET, then CONTAINER_HOST, and defaults to the rootless one
+func newPodmanRuntime() *podmanRuntime {
+	socket := os.Getenv("CN_PODMAN_SOCKET")
+	if socket == "" {
+		socket = os.Getenv("CONTAINER_HOST")
+	}
+	if socket == "" {
+		if os.Geteuid() == 0 {
+			socket = "/run/podman/podman.sock"
+		} else if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+			socket = runtimeDir + "/podman/podman.sock"
+		} else {
+			socket = fmt.Sprintf("/run/user/%d/podman/podman.sock", os.Geteuid())
+		}
+	}
+	socket = strings.TrimPrefix(socket, "unix://")
+
+	return &podmanRuntime{
+		socket: socket,
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+func (p *podmanRuntime) Name() string {
+	return "podman"
+}
+
+// request performs a call on the libpod API, JSON encoding body if any, a reader is sent as a tar archive
+// errors returned by Podman are converted to Go errors, the caller must close the body on success
+func (p *podmanRuntime) request(ctx context.Context, method string, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+		contentType = "application/x-tar"
+	default:
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	// The host is ignored, we always dial the socket
+	target := "http://podman" + podmanAPIPrefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach Podman on %s, is 'podman system service' running? %s", p.socket, err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var podmanErr struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&podmanErr)
+	return nil, fmt.Errorf("Podman error (%d): %s", resp.StatusCode, podmanErr.Message)
+}
+
+// call performs a request and decodes its JSON answer into result, if given
+func (p *podmanRuntime) call(ctx context.Context, method string, path string, query url.Values, body interface{}, result interface{}) error {
+	resp, err := p.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (p *podmanRuntime) CreateContainer(ctx context.Context, spec ContainerSpec) error {
+	type namedVolume struct {
+		Name string `json:"Name"`
+		Dest string `json:"Dest"`
+	}
+	type mount struct {
+		Destination string   `json:"destination"`
+		Type        string   `json:"type"`
+		Source      string   `json:"source"`
+		Options     []string `json:"options"`
+	}
+	type portMapping struct {
+		HostIP        string `json:"host_ip"`
+		ContainerPort uint16 `json:"container_port"`
+		HostPort      uint16 `json:"host_port"`
+		Protocol      string `json:"protocol"`
+	}
+
+	env := map[string]string{}
+	for _, e := range spec.Env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	// Volumes without a name are anonymous, Podman generates one
+	var volumes []namedVolume
+	for _, volume := range spec.Volumes {
+		if parts := strings.SplitN(volume, ":", 2); len(parts) == 2 {
+			volumes = append(volumes, namedVolume{Name: parts[0], Dest: parts[1]})
+		} else {
+			volumes = append(volumes, namedVolume{Dest: volume})
+		}
+	}
+
+	var mounts []mount
+	for _, bind := range spec.Binds {
+		parts := strings.SplitN(bind, ":", 2)
+		mounts = append(mounts, mount{Destination: parts[1], Type: "bind", Source: parts[0], Options: []string{"rbind"}})
+	}
+
+	var portMappings []portMapping
+	for _, port := range spec.Ports {
+		containerPort, _ := strconv.Atoi(port.ContainerPort)
+		hostPort, _ := strconv.Atoi(port.HostPort)
+		portMappings = append(portMappings, portMapping{
+			HostIP:        port.HostIP,
+			ContainerPort: uint16(containerPort),
+			HostPort:      uint16(hostPort),
+			Protocol:      "tcp",
+		})
+	}
+
+	resources := map[string]interface{}{}
+	if spec.Memory > 0 {
+		resources["memory"] = map[string]int64{"limit": spec.Memory}
+	}
+	if spec.NanoCPUs > 0 {
+		// Podman has no NanoCPUs, express it as a CFS quota over the default period
+		period := int64(100000)
+		resources["cpu"] = map[string]int64{"quota": spec.NanoCPUs * period / 1e9, "period": period}
+	}
+
+	specGenerator := map[string]interface{}{
+		"name":         spec.Name,
+		"image":        spec.Image,
+		"hostname":     spec.Hostname,
+		"env":          env,
+		"labels":       spec.Labels,
+		"volumes":      volumes,
+		"mounts":       mounts,
+		"portmappings": portMappings,
+		"privileged":   spec.Privileged,
+	}
+	if len(resources) > 0 {
+		specGenerator["resource_limits"] = resources
+	}
+
+	return p.call(ctx, "POST", "/containers/create", nil, specGenerator, nil)
+}
+
+func (p *podmanRuntime) StartContainer(ctx context.Context, name string) error {
+	return p.call(ctx, "POST", "/containers/"+name+"/start", nil, nil, nil)
+}
+
+func (p *podmanRuntime) StopContainer(ctx context.Context, name string, timeout time.Duration) error {
+	query := url.Values{"timeout": {fmt.Sprint(int(timeout.Seconds()))}}
+	return p.call(ctx, "POST", "/containers/"+name+"/stop", query, nil, nil)
+}
+
+func (p *podmanRuntime) RestartContainer(ctx context.Context, name string, timeout time.Duration) error {
+	query := url.Values{"t": {fmt.Sprint(int(timeout.Seconds()))}}
+	return p.call(ctx, "POST", "/containers/"+name+"/restart", query, nil, nil)
+}
+
+func (p *podmanRuntime) RemoveContainer(ctx context.Context, name string, removeVolumes bool) error {
+	query := url.Values{"force": {"true"}, "v": {strconv.FormatBool(removeVolumes)}}
+	return p.call(ctx, "DELETE", "/containers/"+name, query, nil, nil)
+}
+
+// podmanState maps the Podman container states to the Docker ones cn knows about
+func podmanState(state string) string {
+	switch strings.ToLower(state) {
+	case "configured", "initialized":
+		return "created"
+	case "stopped", "exited":
+		return "exited"
+	}
+	return strings.ToLower(state)
+}
+
+func (p *podmanRuntime) InspectContainer(ctx context.Context, name string) (ContainerInfo, error) {
+	var inspect struct {
+		ID        string `json:"Id"`
+		Name      string
+		Image     string
+		ImageName string
+		State     struct {
+			Status    string
+			StartedAt time.Time
+		}
+		Config struct {
+			Env    []string
+			Labels map[string]string
+		}
+		HostConfig struct {
+			Binds        []string
+			PortBindings map[string][]struct {
+				HostIP   string `json:"HostIp"`
+				HostPort string
+			}
+			Memory     int64
+			NanoCpus   int64
+			CPUQuota   int64  `json:"CpuQuota"`
+			CPUPeriod  uint64 `json:"CpuPeriod"`
+			Privileged bool
+		}
+		Mounts []struct {
+			Type        string
+			Name        string
+			Source      string
+			Destination string
+		}
+	}
+	if err := p.call(ctx, "GET", "/containers/"+name+"/json", nil, nil, &inspect); err != nil {
+		return ContainerInfo{}, err
+	}
+
+	info := ContainerInfo{
+		ID:         inspect.ID,
+		Name:       strings.TrimPrefix(inspect.Name, "/"),
+		Image:      inspect.ImageName,
+		ImageID:    strings.TrimPrefix(inspect.Image, "sha256:"),
+		State:      podmanState(inspect.State.Status),
+		StartedAt:  inspect.State.StartedAt,
+		Env:        inspect.Config.Env,
+		Labels:     inspect.Config.Labels,
+		Binds:      inspect.HostConfig.Binds,
+		Memory:     inspect.HostConfig.Memory,
+		NanoCPUs:   inspect.HostConfig.NanoCpus,
+		Privileged: inspect.HostConfig.Privileged,
+	}
+	if info.NanoCPUs == 0 && inspect.HostConfig.CPUPeriod > 0 {
+		info.NanoCPUs = inspect.HostConfig.CPUQuota * 1e9 / int64(inspect.HostConfig.CPUPeriod)
+	}
+	for port, bindings := range inspect.HostConfig.PortBindings {
+		for _, binding := range bindings {
+			info.Ports = append(info.Ports, PortBinding{
+				HostIP:        binding.HostIP,
+				HostPort:      binding.HostPort,
+				ContainerPort: strings.TrimSuffix(port, "/tcp"),
+			})
+		}
+	}
+	for _, mount := range inspect.Mounts {
+		info.Mounts = append(info.Mounts, ContainerMount{
+			Type:        mount.Type,
+			Name:        mount.Name,
+			Source:      mount.Source,
+			Destination: mount.Destination,
+		})
+	}
+	return info, nil
+}
+
+func (p *podmanRuntime) ListContainers(ctx context.Context, all bool) ([]ContainerInfo, error) {
+	var containers []struct {
+		ID      string `json:"Id"`
+		Names   []string
+		Image   string
+		ImageID string
+		State   string
+		Labels  map[string]string
+		Ports   []struct {
+			HostIP        string `json:"host_ip"`
+			ContainerPort uint16 `json:"container_port"`
+			HostPort      uint16 `json:"host_port"`
+		}
+	}
+	query := url.Values{"all": {strconv.FormatBool(all)}}
+	if err := p.call(ctx, "GET", "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+
+	var infos []ContainerInfo
+	for _, c := range containers {
+		if len(c.Names) == 0 {
+			continue
+		}
+		info := ContainerInfo{
+			ID:      c.ID,
+			Name:    strings.TrimPrefix(c.Names[0], "/"),
+			Image:   c.Image,
+			ImageID: strings.TrimPrefix(c.ImageID, "sha256:"),
+			State:   podmanState(c.State),
+			Labels:  c.Labels,
+		}
+		for _, port := range c.Ports {
+			info.Ports = append(info.Ports, PortBinding{
+				HostIP:        port.HostIP,
+				HostPort:      fmt.Sprint(port.HostPort),
+				ContainerPort: fmt.Sprint(port.ContainerPort),
+			})
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (p *podmanRuntime) ExecContainer(ctx context.Context, name string, cmd []string) ([]byte, int, error) {
+	var exec struct {
+		ID string `json:"Id"`
+	}
+	execConfig := map[string]interface{}{
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          cmd,
+	}
+	if err := p.call(ctx, "POST", "/containers/"+name+"/exec", nil, execConfig, &exec); err != nil {
+		return nil, 0, err
+	}
+
+	// Without Tty, the output comes multiplexed in the body until the command exits
+	resp, err := p.request(ctx, "POST", "/exec/"+exec.ID+"/start", nil, map[string]bool{"Detach": false, "Tty": false})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	output, err := ioutil.ReadAll(demuxLogs(resp.Body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var inspect struct {
+		ExitCode int
+	}
+	if err := p.call(ctx, "GET", "/exec/"+exec.ID+"/json", nil, nil, &inspect); err != nil {
+		return nil, 0, err
+	}
+	return output, inspect.ExitCode, nil
+}
+
+func (p *podmanRuntime) CopyFromContainer(ctx context.Context, name string, path string) (io.ReadCloser, error) {
+	resp, err := p.request(ctx, "GET", "/containers/"+name+"/archive", url.Values{"path": {path}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (p *podmanRuntime) CopyToContainer(ctx context.Context, name string, dir string, archive io.Reader) error {
+	return p.call(ctx, "PUT", "/containers/"+name+"/archive", url.Values{"path": {dir}}, archive, nil)
+}
+
+func (p *podmanRuntime) ContainerLogs(ctx context.Context, name string, follow bool, since time.Time) (io.ReadCloser, error) {
+	query := url.Values{"stdout": {"true"}, "follow": {strconv.FormatBool(follow)}}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339Nano))
+	}
+	resp, err := p.request(ctx, "GET", "/containers/"+name+"/logs", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return demuxLogs(resp.Body), nil
+}
+
+func (p *podmanRuntime) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	if _, err := p.InspectVolume(ctx, name); err != ErrNotFound {
+		return err
+	}
+	return p.call(ctx, "POST", "/volumes/create", nil, map[string]interface{}{"Name": name, "Label": labels}, nil)
+}
+
+// podmanVolume is a volume as returned by the libpod API
+type podmanVolume struct {
+	Name       string
+	Labels     map[string]string
+	Mountpoint string
+}
+
+func (p *podmanRuntime) InspectVolume(ctx context.Context, name string) (VolumeInfo, error) {
+	var v podmanVolume
+	if err := p.call(ctx, "GET", "/volumes/"+name+"/json", nil, nil, &v); err != nil {
+		return VolumeInfo{}, err
+	}
+	return VolumeInfo(v), nil
+}
+
+func (p *podmanRuntime) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	var list []podmanVolume
+	if err := p.call(ctx, "GET", "/volumes/json", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	var volumes []VolumeInfo
+	for _, v := range list {
+		volumes = append(volumes, VolumeInfo(v))
+	}
+	return volumes, nil
+}
+
+func (p *podmanRuntime) RemoveVolume(ctx context.Context, name string) error {
+	return p.call(ctx, "DELETE", "/volumes/"+name, nil, nil, nil)
+}
+
+func (p *podmanRuntime) PullImage(ctx context.Context, image string, progress io.Writer) error {
+	resp, err := p.request(ctx, "POST", "/images/pull", url.Values{"reference": {image}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Podman streams one JSON report per line, the last one tells if the pull failed
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var report struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &report); err == nil && report.Error != "" {
+			return fmt.Errorf("unable to pull %s: %s", image, report.Error)
+		}
+		fmt.Fprint(progress, ".")
+	}
+	return scanner.Err()
+}
+
+func (p *podmanRuntime) InspectImage(ctx context.Context, image string) (ImageInfo, error) {
+	var inspect struct {
+		ID          string `json:"Id"`
+		RepoTags    []string
+		RepoDigests []string
+		Created     string
+		Labels      map[string]string
+	}
+	if err := p.call(ctx, "GET", "/images/"+image+"/json", nil, nil, &inspect); err != nil {
+		return ImageInfo{}, err
+	}
+	return ImageInfo{
+		ID:          strings.TrimPrefix(inspect.ID, "sha256:"),
+		RepoTags:    inspect.RepoTags,
+		RepoDigests: inspect.RepoDigests,
+		Created:     inspect.Created,
+		Labels:      inspect.Labels,
+	}, nil
+}
+
+func (p *podmanRuntime) RemoveImage(ctx context.Context, image string) error {
+	return p.call(ctx, "DELETE", "/images/"+image, url.Values{"force": {"true"}}, nil, nil)
+}
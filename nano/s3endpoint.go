@@ -0,0 +1,240 @@
+package nano
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/minio/minio-go/pkg/s3utils"
+)
+
+// DefaultRegion is the region requests are signed for when none is given, the Rados Gateway accepts any
+const DefaultRegion = "us-east-1"
+
+// cannedACLs are the canned ACLs the Rados Gateway knows about
+var cannedACLs = map[string]bool{
+	"private":            true,
+	"public-read":        true,
+	"public-read-write":  true,
+	"authenticated-read": true,
+}
+
+// S3Endpoint is an S3 service and the credentials to use it, a nano cluster or any other one
+type S3Endpoint struct {
+	URL       string // e.g: http://192.168.0.10:8000 or https://s3.eu-west-1.amazonaws.com
+	AccessKey string
+	SecretKey string
+	Region    string // DefaultRegion when empty
+}
+
+// S3Endpoint returns the S3 gateway of the cluster along with its credentials
+func (c *Cluster) S3Endpoint(ctx context.Context) (*S3Endpoint, error) {
+	if c.AccessKey == "" {
+		if err := c.loadKeys(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if c.Endpoint == "" {
+		return nil, errors.New("unable to find the S3 endpoint of cluster " + c.Name)
+	}
+	return &S3Endpoint{URL: c.Endpoint, AccessKey: c.AccessKey, SecretKey: c.SecretKey}, nil
+}
+
+// region returns the region requests are signed for
+func (e *S3Endpoint) region() string {
+	if e.Region == "" {
+		return DefaultRegion
+	}
+	return e.Region
+}
+
+// Client returns an S3 client for the endpoint
+func (e *S3Endpoint) Client() (*minio.Client, error) {
+	target, err := url.Parse(e.URL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q, the format is http(s)://host:port", e.URL)
+	}
+	return minio.NewWithRegion(target.Host, e.AccessKey, e.SecretKey, target.Scheme == "https", e.region())
+}
+
+// request sends a signed request to the endpoint, for the calls the S3 client does not offer, and returns the response body
+// errors returned by the endpoint are minio.ErrorResponse so minio.ToErrorResponse works on them
+func (e *S3Endpoint) request(ctx context.Context, method, bucket, object string, query url.Values, header http.Header, body []byte) ([]byte, error) {
+	resp, err := e.send(ctx, method, bucket, object, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// send is request leaving the response body to the caller, e.g. to stream an object
+func (e *S3Endpoint) send(ctx context.Context, method, bucket, object string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	target := strings.TrimSuffix(e.URL, "/") + "/" + bucket
+	if object != "" {
+		target += "/" + s3utils.EncodePath(object)
+	}
+	if len(query) > 0 {
+		target += "?" + s3utils.QueryEncode(query)
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	sha := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sha[:]))
+	if len(body) > 0 {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	req = s3signer.SignV4(*req, e.AccessKey, e.SecretKey, "", e.region())
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		errResponse := minio.ErrorResponse{}
+		xml.Unmarshal(content, &errResponse)
+		errResponse.StatusCode = resp.StatusCode
+		if errResponse.Code == "" {
+			errResponse.Code = resp.Status
+			errResponse.Message = fmt.Sprintf("%s %s failed", method, target)
+		}
+		return nil, errResponse
+	}
+	return resp, nil
+}
+
+// versioningConfiguration is the body of the versioning calls
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ VersioningConfiguration"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+// SetBucketVersioning enables or suspends the versioning of a bucket
+// once enabled, versioning can only be suspended, never turned off
+func (e *S3Endpoint) SetBucketVersioning(ctx context.Context, bucket string, enabled bool) error {
+	status := "Suspended"
+	if enabled {
+		status = "Enabled"
+	}
+	body, err := xml.Marshal(versioningConfiguration{Status: status})
+	if err != nil {
+		return err
+	}
+	_, err = e.request(ctx, http.MethodPut, bucket, "", url.Values{"versioning": {""}}, nil, body)
+	return err
+}
+
+// GetBucketVersioning returns the versioning status of a bucket: "Enabled", "Suspended" or "" when it was never enabled
+func (e *S3Endpoint) GetBucketVersioning(ctx context.Context, bucket string) (string, error) {
+	body, err := e.request(ctx, http.MethodGet, bucket, "", url.Values{"versioning": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	var versioning versioningConfiguration
+	if err := xml.Unmarshal(body, &versioning); err != nil {
+		return "", err
+	}
+	return versioning.Status, nil
+}
+
+// tagging is the body of the tagging calls
+type tagging struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ Tagging"`
+	Tags    []s3Tag  `xml:"TagSet>Tag"`
+}
+
+// s3Tag is a tag of a tagging body
+type s3Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// SetObjectTags replaces the tags of an object
+func (e *S3Endpoint) SetObjectTags(ctx context.Context, bucket, object string, tags map[string]string) error {
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var body tagging
+	for _, key := range keys {
+		body.Tags = append(body.Tags, s3Tag{Key: key, Value: tags[key]})
+	}
+	content, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+	_, err = e.request(ctx, http.MethodPut, bucket, object, url.Values{"tagging": {""}}, nil, content)
+	return err
+}
+
+// GetObjectTags returns the tags of an object, nil when it has none
+func (e *S3Endpoint) GetObjectTags(ctx context.Context, bucket, object string) (map[string]string, error) {
+	content, err := e.request(ctx, http.MethodGet, bucket, object, url.Values{"tagging": {""}}, nil, nil)
+	if code := minio.ToErrorResponse(err).Code; code == "NoSuchTagSet" || code == "NoSuchTagSetError" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var body tagging
+	if err := xml.Unmarshal(content, &body); err != nil {
+		return nil, err
+	}
+	if len(body.Tags) == 0 {
+		return nil, nil
+	}
+	tags := map[string]string{}
+	for _, tag := range body.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
+
+// SetACL applies a canned ACL (private, public-read, public-read-write or authenticated-read)
+// to a bucket, or to an object of it when object is not empty
+func (e *S3Endpoint) SetACL(ctx context.Context, bucket, object, acl string) error {
+	if !cannedACLs[acl] {
+		return fmt.Errorf("unknown ACL %q, use private, public-read, public-read-write or authenticated-read", acl)
+	}
+	header := http.Header{"X-Amz-Acl": {acl}}
+	_, err := e.request(ctx, http.MethodPut, bucket, object, url.Values{"acl": {""}}, header, nil)
+	return err
+}
+
+// GetACL returns the canned ACL matching the grants of a bucket, or of an object of it when object is not empty
+// grants given to specific users have no canned equivalent and are not reported, see GetACLGrants
+func (e *S3Endpoint) GetACL(ctx context.Context, bucket, object string) (string, error) {
+	acl, err := e.GetACLGrants(ctx, bucket, object)
+	if err != nil {
+		return "", err
+	}
+	return cannedACL(acl.Grants), nil
+}
@@ -0,0 +1,184 @@
+package nano
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest declares the buckets and objects a cluster is seeded with
+type Manifest struct {
+	Buckets []BucketSeed `yaml:"buckets"`
+}
+
+// BucketSeed declares a bucket of a manifest
+type BucketSeed struct {
+	Name string `yaml:"name"`
+
+	// Versioning enables the versioning of the bucket, false leaves it as is
+	Versioning bool `yaml:"versioning"`
+
+	// ACL is a canned ACL applied to the bucket, e.g: public-read
+	ACL string `yaml:"acl"`
+
+	Objects []ObjectSeed `yaml:"objects"`
+}
+
+// ObjectSeed declares an object of a manifest, its data comes either from File or from Content
+type ObjectSeed struct {
+	Key         string            `yaml:"key"`
+	File        string            `yaml:"file"`
+	Content     string            `yaml:"content"`
+	ContentType string            `yaml:"content_type"`
+	Metadata    map[string]string `yaml:"metadata"`
+	Tags        map[string]string `yaml:"tags"`
+	ACL         string            `yaml:"acl"`
+}
+
+// LoadManifest reads a manifest file, relative object files are relative to the directory of the manifest
+func LoadManifest(fileName string) (*Manifest, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := yaml.UnmarshalStrict(content, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %s", fileName, err)
+	}
+	for _, bucket := range manifest.Buckets {
+		for i, object := range bucket.Objects {
+			if object.File != "" && !filepath.IsAbs(object.File) {
+				bucket.Objects[i].File = filepath.Join(filepath.Dir(fileName), object.File)
+			}
+		}
+	}
+	return &manifest, manifest.validate()
+}
+
+// validate checks a manifest before anything is changed in the cluster
+func (m *Manifest) validate() error {
+	for _, bucket := range m.Buckets {
+		if bucket.Name == "" {
+			return errors.New("a bucket of the manifest has no name")
+		}
+		if bucket.ACL != "" && !cannedACLs[bucket.ACL] {
+			return fmt.Errorf("bucket %s: unknown ACL %q", bucket.Name, bucket.ACL)
+		}
+		for _, object := range bucket.Objects {
+			if object.Key == "" {
+				return fmt.Errorf("bucket %s: an object has no key", bucket.Name)
+			}
+			if object.File != "" && object.Content != "" {
+				return fmt.Errorf("object %s/%s: file and content are exclusive", bucket.Name, object.Key)
+			}
+			if object.ACL != "" && !cannedACLs[object.ACL] {
+				return fmt.Errorf("object %s/%s: unknown ACL %q", bucket.Name, object.Key, object.ACL)
+			}
+		}
+	}
+	return nil
+}
+
+// Seed creates the buckets and objects of a manifest
+// seeding twice is harmless: existing buckets are kept and objects are only uploaded when their content or metadata changed
+func (c *Cluster) Seed(ctx context.Context, manifest *Manifest) error {
+	if err := manifest.validate(); err != nil {
+		return err
+	}
+	endpoint, err := c.S3Endpoint(ctx)
+	if err != nil {
+		return err
+	}
+	s3Client, err := endpoint.Client()
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range manifest.Buckets {
+		err := s3Client.MakeBucket(bucket.Name, "")
+		if code := minio.ToErrorResponse(err).Code; code == "BucketAlreadyOwnedByYou" || code == "BucketAlreadyExists" {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to create bucket %s: %s", bucket.Name, err)
+		}
+		if bucket.Versioning {
+			if err := endpoint.SetBucketVersioning(ctx, bucket.Name, true); err != nil {
+				return fmt.Errorf("unable to enable the versioning of bucket %s: %s", bucket.Name, err)
+			}
+		}
+		if bucket.ACL != "" {
+			if err := endpoint.SetACL(ctx, bucket.Name, "", bucket.ACL); err != nil {
+				return fmt.Errorf("unable to set the ACL of bucket %s: %s", bucket.Name, err)
+			}
+		}
+
+		for _, object := range bucket.Objects {
+			if err := seedObject(ctx, endpoint, s3Client, bucket.Name, object); err != nil {
+				return fmt.Errorf("unable to seed object %s/%s: %s", bucket.Name, object.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// seedObject uploads an object unless an identical one is already there, then applies its tags and ACL
+func seedObject(ctx context.Context, endpoint *S3Endpoint, s3Client *minio.Client, bucket string, object ObjectSeed) error {
+	data := []byte(object.Content)
+	if object.File != "" {
+		var err error
+		if data, err = ioutil.ReadFile(object.File); err != nil {
+			return err
+		}
+	}
+
+	if !objectUpToDate(s3Client, bucket, object, data) {
+		_, err := s3Client.PutObjectWithContext(ctx, bucket, object.Key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+			ContentType:  object.ContentType,
+			UserMetadata: object.Metadata,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(object.Tags) > 0 {
+		if err := endpoint.SetObjectTags(ctx, bucket, object.Key, object.Tags); err != nil {
+			return err
+		}
+	}
+	if object.ACL != "" {
+		return endpoint.SetACL(ctx, bucket, object.Key, object.ACL)
+	}
+	return nil
+}
+
+// objectUpToDate tells whether an object already has the content and metadata of its seed
+func objectUpToDate(s3Client *minio.Client, bucket string, object ObjectSeed, data []byte) bool {
+	info, err := s3Client.StatObject(bucket, object.Key, minio.StatObjectOptions{})
+	if err != nil {
+		return false
+	}
+	sum := md5.Sum(data)
+	if strings.Trim(info.ETag, "\"") != hex.EncodeToString(sum[:]) {
+		return false
+	}
+	if object.ContentType != "" && info.ContentType != object.ContentType {
+		return false
+	}
+	for key, value := range object.Metadata {
+		if info.Metadata.Get("X-Amz-Meta-"+key) != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package nano
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// userDetailsPath is where the demo container writes the keys of the S3 user, outside of the volumes
+const userDetailsPath = "/nano_user_details"
+
+// statePaths are the paths of the container holding the state of a cluster, a snapshot holds one archive each
+func statePaths() []string {
+	return append(append([]string{}, dataPaths...), userDetailsPath)
+}
+
+// snapshotArchive returns the file holding the archive of a path in a snapshot directory
+func snapshotArchive(dir string, containerPath string) string {
+	return filepath.Join(dir, strings.Replace(strings.Trim(containerPath, "/"), "/", "-", -1)+".tar")
+}
+
+// Snapshot saves the configuration and data of the cluster in dir
+// a running cluster is stopped while its volumes are copied, then started again
+func (c *Cluster) Snapshot(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	return c.stopped(ctx, func() error {
+		for _, containerPath := range statePaths() {
+			if err := c.saveArchive(ctx, containerPath, snapshotArchive(dir, containerPath)); err != nil {
+				return fmt.Errorf("unable to snapshot %s: %s", containerPath, err)
+			}
+		}
+		return nil
+	})
+}
+
+// stopped runs fn with the cluster stopped so its state does not change under it
+// a running cluster is started again afterwards, whatever fn returns, and waited for
+func (c *Cluster) stopped(ctx context.Context, fn func() error) error {
+	if c.State != "running" {
+		return fn()
+	}
+
+	if err := c.runtime.StopContainer(ctx, c.ContainerName, 10*time.Second); err != nil {
+		return err
+	}
+	err := fn()
+	if startErr := c.runtime.StartContainer(ctx, c.ContainerName); startErr != nil {
+		if err == nil {
+			err = startErr
+		}
+		return err
+	}
+	if err == nil {
+		err = c.Wait(ctx)
+	}
+	return err
+}
+
+// saveArchive writes the archive of a path of the container to a file, only replacing it once complete
+func (c *Cluster) saveArchive(ctx context.Context, containerPath string, fileName string) error {
+	archive, err := c.runtime.CopyFromContainer(ctx, c.ContainerName, containerPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	file, err := os.OpenFile(fileName+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, archive); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), fileName)
+}
+
+// Restore brings the cluster back to a snapshot taken by Snapshot
+// the container is recreated with fresh volumes the snapshot is copied into, then started
+func (c *Cluster) Restore(ctx context.Context, dir string) error {
+	for _, containerPath := range statePaths() {
+		if _, err := os.Stat(snapshotArchive(dir, containerPath)); err != nil {
+			return fmt.Errorf("incomplete snapshot in %s: %s", dir, err)
+		}
+	}
+
+	info, err := c.runtime.InspectContainer(ctx, c.ContainerName)
+	if err != nil {
+		return err
+	}
+	if err := c.runtime.RemoveContainer(ctx, c.ContainerName, true); err != nil {
+		return err
+	}
+	if c.Persist {
+		if err := removeVolumes(ctx, c.runtime, c.ContainerName); err != nil {
+			return err
+		}
+		if err := prepareVolumes(ctx, c.runtime, c.ContainerName, &c.Metadata); err != nil {
+			return err
+		}
+	}
+	if err := c.runtime.CreateContainer(ctx, c.Metadata.containerSpec(c.ContainerName, info.Privileged)); err != nil {
+		return err
+	}
+
+	for _, containerPath := range statePaths() {
+		archive, err := os.Open(snapshotArchive(dir, containerPath))
+		if err != nil {
+			return err
+		}
+		// Archives are rooted at the last element of the path they were taken from
+		err = c.runtime.CopyToContainer(ctx, c.ContainerName, path.Dir(containerPath), archive)
+		archive.Close()
+		if err != nil {
+			return fmt.Errorf("unable to restore %s: %s", containerPath, err)
+		}
+	}
+
+	if err := c.runtime.StartContainer(ctx, c.ContainerName); err != nil {
+		return err
+	}
+	c.State = "running"
+	c.AccessKey, c.SecretKey = "", ""
+	return c.Wait(ctx)
+}
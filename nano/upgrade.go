@@ -0,0 +1,139 @@
+package nano
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// UpgradeError is returned when a cluster is not healthy on its new image
+// the cluster is rolled back to its previous image, RollbackErr tells if that failed too
+type UpgradeError struct {
+	Cluster         string
+	Image           string
+	PreviousImageID string // the image the cluster is rolled back to, its tag may point to Image by now
+	Err             error
+	RollbackErr     error
+}
+
+func (e *UpgradeError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("cluster %s failed on image %s (%s) and could not be rolled back: %s", e.Cluster, e.Image, e.Err, e.RollbackErr)
+	}
+	return fmt.Sprintf("cluster %s failed on image %s, it was rolled back: %s", e.Cluster, e.Image, e.Err)
+}
+
+// Upgrade recreates the cluster on another image, keeping its data volumes, port, work dir and resources
+// the new image is pulled first; if the cluster is not healthy on it, or ctx is done before, it goes back to its previous image
+func (c *Cluster) Upgrade(ctx context.Context, image string, progress io.Writer) error {
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+	fmt.Fprint(progress, "Pulling image "+image+"...")
+	err := c.runtime.PullImage(ctx, image, progress)
+	fmt.Fprintln(progress)
+	if err != nil {
+		return err
+	}
+	newImage, err := c.runtime.InspectImage(ctx, image)
+	if err != nil {
+		return err
+	}
+	if newImage.ID == c.ImageID {
+		fmt.Fprintln(progress, "Cluster "+c.Name+" already runs image "+image+".")
+		return nil
+	}
+
+	info, err := c.runtime.InspectContainer(ctx, c.ContainerName)
+	if err != nil {
+		return err
+	}
+	// The volumes of the container are given by name to the new one, anonymous volumes have a generated name too
+	var volumes []string
+	for _, mount := range info.Mounts {
+		if mount.Type == "volume" && mount.Name != "" {
+			volumes = append(volumes, mount.Name+":"+mount.Destination)
+		}
+	}
+	if len(volumes) != len(dataPaths) {
+		return fmt.Errorf("unable to find the data volumes of cluster %s", c.Name)
+	}
+
+	// The keys of the S3 user live outside of the volumes, without them they are asked to radosgw-admin
+	userDetails, _ := c.readArchive(ctx, userDetailsPath)
+
+	fmt.Fprintln(progress, "Stopping cluster "+c.Name+"...")
+	previous, previousImageID := c.Metadata, c.ImageID
+	if err := c.Stop(); err != nil {
+		return err
+	}
+	if err := c.runtime.RemoveContainer(ctx, c.ContainerName, false); err != nil {
+		return err
+	}
+
+	upgraded := previous
+	upgraded.Image = image
+	fmt.Fprintln(progress, "Starting cluster "+c.Name+" on image "+image+"...")
+	err = c.recreate(ctx, upgraded, image, info.Privileged, volumes, userDetails)
+	if err == nil {
+		return nil
+	}
+
+	fmt.Fprintln(progress, "Cluster "+c.Name+" is not healthy on image "+image+", rolling back to image "+ShortImageID(previousImageID)+"...")
+	upgradeErr := &UpgradeError{Cluster: c.Name, Image: image, PreviousImageID: previousImageID, Err: err}
+	// ctx may be what failed the upgrade, e.g. cancelled on Ctrl-C, the rollback gets a context of its own
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	if err := c.runtime.RemoveContainer(rollbackCtx, c.ContainerName, false); err != nil && err != ErrNotFound {
+		upgradeErr.RollbackErr = err
+		return upgradeErr
+	}
+	// The previous image is given by ID, pulling the new image may have moved its tag
+	upgradeErr.RollbackErr = c.recreate(rollbackCtx, previous, previousImageID, info.Privileged, volumes, userDetails)
+	return upgradeErr
+}
+
+// recreate creates and starts the cluster container from metadata on an image, on existing volumes, and waits for it
+func (c *Cluster) recreate(ctx context.Context, m Metadata, image string, privileged bool, volumes []string, userDetails []byte) error {
+	spec := m.containerSpec(c.ContainerName, privileged)
+	spec.Image = image
+	spec.Volumes = volumes
+	if err := c.runtime.CreateContainer(ctx, spec); err != nil {
+		return err
+	}
+	if userDetails != nil {
+		if err := c.runtime.CopyToContainer(ctx, c.ContainerName, "/", bytes.NewReader(userDetails)); err != nil {
+			return err
+		}
+	}
+	if err := c.runtime.StartContainer(ctx, c.ContainerName); err != nil {
+		return err
+	}
+
+	updated, err := Get(ctx, c.runtime, c.Name)
+	if err != nil {
+		return err
+	}
+	*c = *updated
+	return c.Wait(ctx)
+}
+
+// readArchive returns the archive of a path of the cluster container
+func (c *Cluster) readArchive(ctx context.Context, containerPath string) ([]byte, error) {
+	archive, err := c.runtime.CopyFromContainer(ctx, c.ContainerName, containerPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return ioutil.ReadAll(archive)
+}
+
+// ShortImageID returns the first 12 characters of an image ID, as the runtimes display it
+func ShortImageID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,255 @@
+package nano
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// User is an S3 user of a cluster
+type User struct {
+	UID         string
+	DisplayName string
+	AccessKey   string
+	SecretKey   string
+	Suspended   bool
+	Subusers    []Subuser
+}
+
+// Subuser is an identity of a user with keys of its own and a subset of its permissions
+type Subuser struct {
+	ID          string // 'UID:NAME'
+	Permissions string // e.g: "read", "write", "read-write" or "full-control"
+	AccessKey   string
+	SecretKey   string
+}
+
+// Keys returns the S3 keys of the user, or of one of its subusers given its 'UID:NAME' ID
+func (u User) Keys(id string) (string, string, bool) {
+	if id == u.UID {
+		return u.AccessKey, u.SecretKey, u.AccessKey != ""
+	}
+	for _, subuser := range u.Subusers {
+		if subuser.ID == id {
+			return subuser.AccessKey, subuser.SecretKey, subuser.AccessKey != ""
+		}
+	}
+	return "", "", false
+}
+
+// splitUserID splits the ID of a user or subuser, 'UID' or 'UID:NAME', the subuser part is empty for a user
+func splitUserID(id string) (string, string) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// radosgwUser is the part of the 'radosgw-admin user info' output cn uses
+type radosgwUser struct {
+	UserID      string `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	Suspended   int    `json:"suspended"`
+	Subusers    []struct {
+		ID          string `json:"id"`
+		Permissions string `json:"permissions"`
+	} `json:"subusers"`
+	Keys []struct {
+		User      string `json:"user"`
+		AccessKey string `json:"access_key"`
+		SecretKey string `json:"secret_key"`
+	} `json:"keys"`
+}
+
+// toUser converts the radosgw-admin representation of a user, only the first key of each identity is kept
+func (r radosgwUser) toUser() User {
+	user := User{UID: r.UserID, DisplayName: r.DisplayName, Suspended: r.Suspended != 0}
+	for _, subuser := range r.Subusers {
+		user.Subusers = append(user.Subusers, Subuser{ID: subuser.ID, Permissions: subuser.Permissions})
+	}
+	for i := len(r.Keys) - 1; i >= 0; i-- {
+		key := r.Keys[i]
+		if key.User == r.UserID {
+			user.AccessKey, user.SecretKey = key.AccessKey, key.SecretKey
+		}
+		for j := range user.Subusers {
+			if key.User == user.Subusers[j].ID {
+				user.Subusers[j].AccessKey, user.Subusers[j].SecretKey = key.AccessKey, key.SecretKey
+			}
+		}
+	}
+	return user
+}
+
+// radosgwAdmin runs radosgw-admin inside the cluster and decodes its JSON output into result
+func (c *Cluster) radosgwAdmin(ctx context.Context, result interface{}, args ...string) error {
+	output, err := c.Exec(ctx, append([]string{"radosgw-admin"}, args...))
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(output, result); err != nil {
+		return fmt.Errorf("unable to read the output of radosgw-admin %s: %s", args[0], err)
+	}
+	return nil
+}
+
+// GetUser returns an S3 user of the cluster
+func (c *Cluster) GetUser(ctx context.Context, uid string) (User, error) {
+	var user radosgwUser
+	if err := c.radosgwAdmin(ctx, &user, "user", "info", "--uid="+uid); err != nil {
+		return User{}, err
+	}
+	return user.toUser(), nil
+}
+
+// CreateUser creates an S3 user, keys are generated unless user gives them
+// creating a user that already exists returns it as is, unless the keys given are not its own
+func (c *Cluster) CreateUser(ctx context.Context, user User) (User, error) {
+	if existing, err := c.GetUser(ctx, user.UID); err == nil {
+		if (user.AccessKey != "" && user.AccessKey != existing.AccessKey) || (user.SecretKey != "" && user.SecretKey != existing.SecretKey) {
+			return User{}, fmt.Errorf("user %s already exists with other keys", user.UID)
+		}
+		return existing, nil
+	}
+
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.UID
+	}
+	args := []string{"user", "create", "--uid=" + user.UID, "--display-name=" + displayName}
+	if user.AccessKey != "" {
+		args = append(args, "--access-key="+user.AccessKey)
+	}
+	if user.SecretKey != "" {
+		args = append(args, "--secret-key="+user.SecretKey)
+	}
+
+	var created radosgwUser
+	if err := c.radosgwAdmin(ctx, &created, args...); err != nil {
+		return User{}, err
+	}
+	return created.toUser(), nil
+}
+
+// ListUsers returns the S3 users of the cluster, sorted by UID
+func (c *Cluster) ListUsers(ctx context.Context) ([]User, error) {
+	var uids []string
+	if err := c.radosgwAdmin(ctx, &uids, "user", "list"); err != nil {
+		return nil, err
+	}
+	sort.Strings(uids)
+
+	var users []User
+	for _, uid := range uids {
+		user, err := c.GetUser(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// RemoveUser removes a user along with its subusers, or a subuser given its 'UID:NAME' ID
+// the buckets and objects of a user are removed with it when purgeData is set, radosgw-admin refuses otherwise
+func (c *Cluster) RemoveUser(ctx context.Context, id string, purgeData bool) error {
+	if id == c.User {
+		return errors.New("user " + id + " is the user of cluster " + c.Name + ", it can not be removed")
+	}
+	if _, subuser := splitUserID(id); subuser != "" {
+		return c.radosgwAdmin(ctx, nil, "subuser", "rm", "--subuser="+id, "--purge-keys")
+	}
+	args := []string{"user", "rm", "--uid=" + id}
+	if purgeData {
+		args = append(args, "--purge-data")
+	}
+	return c.radosgwAdmin(ctx, nil, args...)
+}
+
+// CreateSubuser creates a subuser, given its 'UID:NAME' ID, with S3 keys of its own
+// permissions is one of "read", "write", "readwrite" or "full"
+func (c *Cluster) CreateSubuser(ctx context.Context, id string, permissions string) (User, error) {
+	uid, subuser := splitUserID(id)
+	if subuser == "" {
+		return User{}, fmt.Errorf("invalid subuser %q, the format is UID:NAME", id)
+	}
+	var user radosgwUser
+	err := c.radosgwAdmin(ctx, &user, "subuser", "create", "--uid="+uid, "--subuser="+id, "--access="+permissions,
+		"--key-type=s3", "--gen-access-key", "--gen-secret")
+	if err != nil {
+		return User{}, err
+	}
+	return user.toUser(), nil
+}
+
+// RotateKey replaces the S3 keys of a user, or of a subuser given its 'UID:NAME' ID, with new ones
+// rotating the keys of the user of the cluster updates the keys the cluster uses too
+func (c *Cluster) RotateKey(ctx context.Context, id string) (User, error) {
+	uid, subuser := splitUserID(id)
+	identity := "--uid=" + uid
+	if subuser != "" {
+		identity = "--subuser=" + id
+	}
+	current, err := c.GetUser(ctx, uid)
+	if err != nil {
+		return User{}, err
+	}
+	oldKey, _, _ := current.Keys(id)
+
+	var rotated radosgwUser
+	if err := c.radosgwAdmin(ctx, &rotated, "key", "create", identity, "--key-type=s3", "--gen-access-key", "--gen-secret"); err != nil {
+		return User{}, err
+	}
+	if oldKey != "" {
+		if err := c.radosgwAdmin(ctx, nil, "key", "rm", identity, "--key-type=s3", "--access-key="+oldKey); err != nil {
+			return User{}, err
+		}
+	}
+	user, err := c.GetUser(ctx, uid)
+	if err != nil {
+		return User{}, err
+	}
+
+	if id == c.User {
+		// The keys of the cluster are read from the user details first, they must not point to the removed key
+		if _, err := c.Exec(ctx, []string{"sh", "-c", "radosgw-admin user info --uid=" + uid + " > " + userDetailsPath}); err != nil {
+			return User{}, err
+		}
+		c.AccessKey, c.SecretKey = user.AccessKey, user.SecretKey
+	}
+	return user, nil
+}
+
+// SuspendUser suspends a user, its requests are denied until EnableUser
+func (c *Cluster) SuspendUser(ctx context.Context, uid string) error {
+	return c.radosgwAdmin(ctx, nil, "user", "suspend", "--uid="+uid)
+}
+
+// EnableUser enables a user suspended with SuspendUser
+func (c *Cluster) EnableUser(ctx context.Context, uid string) error {
+	return c.radosgwAdmin(ctx, nil, "user", "enable", "--uid="+uid)
+}
+
+// UserS3Endpoint returns the S3 gateway of the cluster with the keys of a user, or of a subuser given its 'UID:NAME' ID
+func (c *Cluster) UserS3Endpoint(ctx context.Context, id string) (*S3Endpoint, error) {
+	uid, _ := splitUserID(id)
+	user, err := c.GetUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	accessKey, secretKey, ok := user.Keys(id)
+	if !ok {
+		return nil, errors.New("user " + id + " of cluster " + c.Name + " has no S3 keys")
+	}
+	if c.Endpoint == "" {
+		return nil, errors.New("unable to find the S3 endpoint of cluster " + c.Name)
+	}
+	return &S3Endpoint{URL: c.Endpoint, AccessKey: accessKey, SecretKey: secretKey}, nil
+}
@@ -0,0 +1,106 @@
+package nano
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ObjectVersion is a version of an object or a delete marker, see ListObjectVersions
+type ObjectVersion struct {
+	Key          string
+	VersionID    string
+	IsLatest     bool
+	DeleteMarker bool
+	LastModified time.Time
+	Size         int64
+	ETag         string
+}
+
+// listVersionsResult is the body of a versions listing
+// versions and delete markers are interleaved in the order of the listing, they land in Entries
+type listVersionsResult struct {
+	IsTruncated         bool
+	NextKeyMarker       string
+	NextVersionIDMarker string         `xml:"NextVersionIdMarker"`
+	CommonPrefixes      []commonPrefix `xml:"CommonPrefixes"`
+	Entries             []versionEntry `xml:",any"`
+}
+
+// commonPrefix is a prefix of a listing using a delimiter
+type commonPrefix struct {
+	Prefix string
+}
+
+// versionEntry is a Version or a DeleteMarker element of a versions listing, other elements are ignored
+type versionEntry struct {
+	XMLName      xml.Name
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified time.Time
+	Size         int64
+	ETag         string
+}
+
+// ListObjectVersions returns the versions and delete markers of the objects of a bucket under a prefix
+// unless recursive, the objects are listed one level down the prefix and the levels below it are returned
+// as entries without version, their key ends with "/"
+func (e *S3Endpoint) ListObjectVersions(ctx context.Context, bucket string, prefix string, recursive bool) ([]ObjectVersion, error) {
+	query := url.Values{"versions": {""}, "prefix": {prefix}}
+	if !recursive {
+		query.Set("delimiter", "/")
+	}
+
+	var versions []ObjectVersion
+	for {
+		body, err := e.request(ctx, http.MethodGet, bucket, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listVersionsResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			if entry.XMLName.Local != "Version" && entry.XMLName.Local != "DeleteMarker" {
+				continue
+			}
+			versions = append(versions, ObjectVersion{
+				Key:          entry.Key,
+				VersionID:    entry.VersionID,
+				IsLatest:     entry.IsLatest,
+				DeleteMarker: entry.XMLName.Local == "DeleteMarker",
+				LastModified: entry.LastModified,
+				Size:         entry.Size,
+				ETag:         entry.ETag,
+			})
+		}
+		for _, common := range result.CommonPrefixes {
+			versions = append(versions, ObjectVersion{Key: common.Prefix})
+		}
+		if !result.IsTruncated {
+			return versions, nil
+		}
+		query.Set("key-marker", result.NextKeyMarker)
+		query.Set("version-id-marker", result.NextVersionIDMarker)
+	}
+}
+
+// GetObjectVersion returns the content of a version of an object, to be closed by the caller
+func (e *S3Endpoint) GetObjectVersion(ctx context.Context, bucket, object, versionID string) (io.ReadCloser, error) {
+	resp, err := e.send(ctx, http.MethodGet, bucket, object, url.Values{"versionId": {versionID}}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// RemoveObjectVersion removes a version of an object for good, or a delete marker which brings back the version before it
+func (e *S3Endpoint) RemoveObjectVersion(ctx context.Context, bucket, object, versionID string) error {
+	_, err := e.request(ctx, http.MethodDelete, bucket, object, url.Values{"versionId": {versionID}}, nil, nil)
+	return err
+}
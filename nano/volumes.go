@@ -0,0 +1,121 @@
+package nano
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// labels of the named volumes of persistent clusters
+const (
+	labelVolumeCluster = "io.ceph.nano.cluster"
+)
+
+// Volume is a named volume holding the data of a persistent cluster
+type Volume struct {
+	Name       string
+	Cluster    string // name of the cluster the volume belongs to
+	Mountpoint string
+	Orphan     bool // the cluster does not exist anymore
+}
+
+// volumeName returns the name of the volume holding a path of a persistent cluster
+func volumeName(containerName string, containerPath string) string {
+	return containerName + "-" + strings.Replace(strings.Trim(containerPath, "/"), "/", "-", -1)
+}
+
+// prepareVolumes creates the missing volumes of a persistent cluster
+// when the volumes already exist, the data they hold expects the S3 gateway on the container port they were created with
+func prepareVolumes(ctx context.Context, rt Runtime, containerName string, m *Metadata) error {
+	for _, containerPath := range dataPaths {
+		name := volumeName(containerName, containerPath)
+		info, err := rt.InspectVolume(ctx, name)
+		if err == nil {
+			if port := info.Labels[labelContainerPort]; port != "" {
+				m.ContainerPort = port
+			}
+			continue
+		}
+		if err != ErrNotFound {
+			return err
+		}
+		labels := map[string]string{
+			labelVolumeCluster: strings.TrimPrefix(containerName, ContainerNamePrefix),
+			labelContainerPort: m.ContainerPort,
+		}
+		if err := rt.CreateVolume(ctx, name, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeVolumes removes the volumes of a persistent cluster, volumes already gone are not an error
+func removeVolumes(ctx context.Context, rt Runtime, containerName string) error {
+	for _, containerPath := range dataPaths {
+		if err := rt.RemoveVolume(ctx, volumeName(containerName, containerPath)); err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListVolumes returns the volumes of the persistent clusters, sorted by name
+func ListVolumes(ctx context.Context, rt Runtime) ([]Volume, error) {
+	rt, err := runtimeOrDefault(rt)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := rt.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := List(ctx, rt)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]bool{}
+	for _, cluster := range clusters {
+		existing[cluster.Name] = true
+	}
+
+	var volumes []Volume
+	for _, info := range infos {
+		cluster, ok := info.Labels[labelVolumeCluster]
+		if !ok {
+			continue
+		}
+		volumes = append(volumes, Volume{
+			Name:       info.Name,
+			Cluster:    cluster,
+			Mountpoint: info.Mountpoint,
+			Orphan:     !existing[cluster],
+		})
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+// RemoveVolume removes a volume of a persistent cluster that does not exist anymore, see Volume.Orphan
+// the volumes of an existing cluster are removed along with it by Purge
+func RemoveVolume(ctx context.Context, rt Runtime, name string) error {
+	rt, err := runtimeOrDefault(rt)
+	if err != nil {
+		return err
+	}
+	volumes, err := ListVolumes(ctx, rt)
+	if err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		if volume.Name != name {
+			continue
+		}
+		if !volume.Orphan {
+			return fmt.Errorf("volume %s is used by cluster %s, purge the cluster instead", name, volume.Cluster)
+		}
+		return rt.RemoveVolume(ctx, name)
+	}
+	return fmt.Errorf("volume %s does not belong to a cluster", name)
+}
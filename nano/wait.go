@@ -0,0 +1,136 @@
+package nano
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// kinds of conditions WaitFor waits for
+const (
+	ConditionHealthy = "healthy" // 'ceph health' is HEALTH_OK, or HEALTH_WARN with singleNodeWarnings only
+	ConditionS3      = "s3"      // the S3 gateway answers requests signed with the keys of the cluster
+	ConditionBucket  = "bucket"  // a bucket exists
+)
+
+// singleNodeWarnings are the health checks a cluster with a single OSD raises on newer images, they do not make it unhealthy
+var singleNodeWarnings = map[string]bool{
+	"POOL_NO_REDUNDANCY":                      true, // pools without replicas
+	"AUTH_INSECURE_GLOBAL_ID_RECLAIM_ALLOWED": true, // the mon allows insecure global_id reclaim
+}
+
+// Condition is a state of a cluster WaitFor waits for
+type Condition struct {
+	Kind   string // ConditionHealthy, ConditionS3 or ConditionBucket
+	Bucket string // the bucket of ConditionBucket
+}
+
+// ParseCondition parses a condition written as 'healthy', 's3' or 'bucket=NAME'
+func ParseCondition(s string) (Condition, error) {
+	parts := strings.SplitN(s, "=", 2)
+	switch {
+	case len(parts) == 1 && (s == ConditionHealthy || s == ConditionS3):
+		return Condition{Kind: s}, nil
+	case len(parts) == 2 && parts[0] == ConditionBucket && parts[1] != "":
+		return Condition{Kind: ConditionBucket, Bucket: parts[1]}, nil
+	}
+	return Condition{}, fmt.Errorf("invalid condition %q, it is one of healthy, s3 or bucket=NAME", s)
+}
+
+func (c Condition) String() string {
+	if c.Kind == ConditionBucket {
+		return c.Kind + "=" + c.Bucket
+	}
+	return c.Kind
+}
+
+// ConditionError is returned by WaitFor when a condition does not hold before the deadline
+type ConditionError struct {
+	Cluster   string
+	Condition Condition
+	Last      error // why the condition did not hold on the last check
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("timed out waiting for %s on cluster %s: %s", e.Condition, e.Cluster, e.Last)
+}
+
+// WaitFor checks a condition every second until it holds, for DefaultTimeout unless ctx has a deadline
+// a ConditionError is returned when the deadline is reached, ctx.Err() when ctx is cancelled
+func (c *Cluster) WaitFor(ctx context.Context, condition Condition) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	for {
+		last := c.check(ctx, condition)
+		if last == nil {
+			return nil
+		}
+		err := sleep(ctx, time.Second)
+		if err == context.DeadlineExceeded {
+			return &ConditionError{Cluster: c.Name, Condition: condition, Last: last}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// check tells why a condition does not hold, nil when it does
+func (c *Cluster) check(ctx context.Context, condition Condition) error {
+	if condition.Kind == ConditionHealthy {
+		return c.checkHealthy(ctx)
+	}
+
+	// Unlike curlTestURL, any answer is not enough: the request is signed and must succeed
+	endpoint, err := c.S3Endpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if condition.Kind == ConditionBucket {
+		_, err = endpoint.request(ctx, http.MethodGet, condition.Bucket, "", url.Values{"location": {""}}, nil, nil)
+		return err
+	}
+	_, err = endpoint.request(ctx, http.MethodGet, "", "", nil, nil, nil)
+	return err
+}
+
+// checkHealthy tells why the cluster is not healthy, nil when it is, see ConditionHealthy
+func (c *Cluster) checkHealthy(ctx context.Context) error {
+	output, err := c.Exec(ctx, []string{"ceph", "health", "--format", "json"})
+	if err != nil {
+		return err
+	}
+	var health struct {
+		Status string                     `json:"status"`
+		Checks map[string]json.RawMessage `json:"checks"`
+	}
+	if err := json.Unmarshal(output, &health); err != nil {
+		return fmt.Errorf("unable to read the health of cluster %s: %s", c.Name, err)
+	}
+	if health.Status == "HEALTH_OK" {
+		return nil
+	}
+
+	var checks []string
+	for check := range health.Checks {
+		if !singleNodeWarnings[check] {
+			checks = append(checks, check)
+		}
+	}
+	if len(checks) == 0 {
+		if health.Status == "HEALTH_WARN" {
+			return nil
+		}
+		return fmt.Errorf("ceph health is %s", health.Status)
+	}
+	sort.Strings(checks)
+	return fmt.Errorf("ceph health is %s: %s", health.Status, strings.Join(checks, ", "))
+}